@@ -0,0 +1,145 @@
+package async
+
+import (
+	"context"
+	"io"
+
+	"github.com/dihedron/go-log-facade/logging"
+)
+
+// Logger is a logger that hands entries over to a background goroutine,
+// which replays them onto the wrapped Logger, so that slow sinks do not
+// block the caller. The queue is bounded and its overflow policy is
+// configurable; since entries are written later, their arguments must
+// not be modified after the logging call.
+type Logger struct {
+	logging.Wrapper
+	queue *queue
+}
+
+// NewLogger returns an asynchronous Logger wrapping the given Logger.
+func NewLogger(logger logging.Logger, options ...Option) *Logger {
+	c := newConfig(options...)
+	q := newQueue(c, func(i item) {
+		i.entry.Replay(i.logger)
+	}, func(dropped uint64) {
+		logger.Warnw("dropped log entries", "count", dropped)
+	})
+	l := &Logger{queue: q}
+	l.Wrapper = logging.NewWrapper(logger, l, l.derive)
+	return l
+}
+
+// derive returns a child Logger sharing the same queue.
+func (l *Logger) derive(w logging.Wrapper) logging.Logger {
+	child := &Logger{queue: l.queue}
+	child.Wrapper = w.Bind(child, child.derive)
+	return child
+}
+
+// Handle queues the entry; panic and fatal entries are replayed
+// synchronously, after all queued entries have been written.
+func (l *Logger) Handle(entry *logging.Entry) {
+	if entry.Level >= logging.LevelPanic {
+		_ = l.queue.flush(context.Background())
+		entry.Replay(l.Unwrap())
+		return
+	}
+	l.queue.push(item{logger: l.Unwrap(), entry: entry}, entry.Level)
+}
+
+// Dropped returns the total number of entries dropped so far.
+func (l *Logger) Dropped() uint64 {
+	return l.queue.dropped.Load()
+}
+
+// Flush waits until all the entries logged so far have been written and
+// flushes the wrapped Logger, if it supports it.
+func (l *Logger) Flush(ctx context.Context) error {
+	if err := l.queue.flush(ctx); err != nil {
+		return err
+	}
+	if syncer, ok := l.Unwrap().(interface{ Sync() error }); ok {
+		return syncer.Sync()
+	}
+	return nil
+}
+
+// Sync flushes the Logger, waiting with no timeout.
+func (l *Logger) Sync() error {
+	return l.Flush(context.Background())
+}
+
+// Close writes all the queued entries, stops the background goroutine
+// and closes the wrapped Logger, if it supports it; entries logged after
+// Close are dropped.
+func (l *Logger) Close() error {
+	if !l.queue.close() {
+		return nil
+	}
+	if closer, ok := l.Unwrap().(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Writer is an io.Writer that copies the data and writes it to the
+// wrapped io.Writer from a background goroutine; it can be passed to
+// stream.NewLogger to move slow I/O off the logging path, with the level
+// of all data being considered LevelInfo by the DropBelow policy.
+type Writer struct {
+	writer io.Writer
+	queue  *queue
+}
+
+// NewWriter returns an asynchronous Writer wrapping the given io.Writer.
+func NewWriter(writer io.Writer, options ...Option) *Writer {
+	c := newConfig(options...)
+	return &Writer{
+		writer: writer,
+		queue: newQueue(c, func(i item) {
+			_, _ = writer.Write(i.data)
+		}, nil),
+	}
+}
+
+// Write queues a copy of the data; it never returns an error, even when
+// the data is dropped.
+func (w *Writer) Write(data []byte) (int, error) {
+	w.queue.push(item{data: append([]byte(nil), data...)}, logging.LevelInfo)
+	return len(data), nil
+}
+
+// Dropped returns the total number of writes dropped so far.
+func (w *Writer) Dropped() uint64 {
+	return w.queue.dropped.Load()
+}
+
+// Flush waits until all the data written so far has been passed on to
+// the wrapped io.Writer, and syncs it if it supports it.
+func (w *Writer) Flush(ctx context.Context) error {
+	if err := w.queue.flush(ctx); err != nil {
+		return err
+	}
+	if syncer, ok := w.writer.(interface{ Sync() error }); ok {
+		return syncer.Sync()
+	}
+	return nil
+}
+
+// Sync flushes the Writer, waiting with no timeout.
+func (w *Writer) Sync() error {
+	return w.Flush(context.Background())
+}
+
+// Close writes all the queued data, stops the background goroutine and
+// closes the wrapped io.Writer, if it supports it.
+func (w *Writer) Close() error {
+	if !w.queue.close() {
+		return nil
+	}
+	if closer, ok := w.writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package async
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dihedron/go-log-facade/logging"
+	"github.com/dihedron/go-log-facade/logging/stream"
+)
+
+// gatedWriter is a writer whose writes block until the gate is opened; it
+// signals each write as it starts.
+type gatedWriter struct {
+	gate    chan struct{}
+	opening sync.Once
+	started chan struct{}
+	lock    sync.Mutex
+	buffer  bytes.Buffer
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{
+		gate:    make(chan struct{}),
+		started: make(chan struct{}, 1000),
+	}
+}
+
+func (w *gatedWriter) Write(data []byte) (int, error) {
+	w.started <- struct{}{}
+	<-w.gate
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.buffer.Write(data)
+}
+
+func (w *gatedWriter) open() {
+	w.opening.Do(func() { close(w.gate) })
+}
+
+func (w *gatedWriter) String() string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.buffer.String()
+}
+
+var message = regexp.MustCompile(`msg=(\S+)`)
+
+// messages returns the messages written, in order.
+func (w *gatedWriter) messages() []string {
+	var messages []string
+	for _, match := range message.FindAllStringSubmatch(w.String(), -1) {
+		messages = append(messages, match[1])
+	}
+	return messages
+}
+
+// newLogger returns an asynchronous Logger writing to a gated writer
+// through a logfmt stream Logger; both are released when the test ends.
+func newLogger(t *testing.T, options ...Option) (*Logger, *gatedWriter) {
+	writer := newGatedWriter()
+	inner := stream.NewLogger(writer, stream.WithEncoder(&stream.LogfmtEncoder{}), stream.WithCaller(logging.CallerOff))
+	inner.SetLevel(logging.LevelTrace)
+	logger := NewLogger(inner, options...)
+	t.Cleanup(func() {
+		writer.open()
+		logger.Close()
+	})
+	return logger, writer
+}
+
+// blocked returns whether the channel is still open after a short while.
+func blocked(done chan struct{}) bool {
+	select {
+	case <-done:
+		return false
+	case <-time.After(50 * time.Millisecond):
+		return true
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		option  Option
+		blocks  bool
+		want    string
+		dropped uint64
+	}{
+		{"Block", WithPolicy(Block), true, "0 1 2 3 4", 0},
+		{"DropNewest", WithPolicy(DropNewest), false, "0 1 2", 2},
+		{"DropOldest", WithPolicy(DropOldest), false, "0 3 4", 2},
+		{"DropBelow", WithDropBelow(logging.LevelWarn), true, "0 1 2 4", 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logger, writer := newLogger(t, WithQueueSize(2), test.option)
+
+			// the first entry is being written, the next two fill the queue
+			logger.Info("0")
+			<-writer.started
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				logger.Info("1")
+				logger.Info("2")
+				logger.Info("3")
+				logger.Warn("4")
+			}()
+			if got := blocked(done); got != test.blocks {
+				t.Errorf("blocked = %t, want %t", got, test.blocks)
+			}
+			writer.open()
+			<-done
+			if err := logger.Flush(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := strings.Join(writer.messages(), " "); got != test.want {
+				t.Errorf("got messages %s, want %s", got, test.want)
+			}
+			if got := logger.Dropped(); got != test.dropped {
+				t.Errorf("Dropped() = %d, want %d", got, test.dropped)
+			}
+		})
+	}
+}
+
+// TestDropOldestKeepsFlushMarkers checks that Flush does not return before
+// the entries logged before it are written, even when DropOldest makes
+// room for new entries while the flush marker is at the head of the queue.
+func TestDropOldestKeepsFlushMarkers(t *testing.T) {
+	logger, writer := newLogger(t, WithQueueSize(2), WithPolicy(DropOldest))
+
+	logger.Info("0")
+	<-writer.started
+	logger.Info("1")
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+		// the wrapped Logger's Sync would wait for the write in progress
+		_ = logger.queue.flush(context.Background())
+	}()
+	for len(logger.queue.items) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	logger.Info("2")
+	logger.Info("3")
+	if !blocked(flushed) {
+		t.Fatal("Flush returned before the entries logged before it were written")
+	}
+	writer.open()
+	<-flushed
+	if got := writer.messages(); len(got) == 0 || got[0] != "0" {
+		t.Errorf("got messages %v after Flush", got)
+	}
+}
+
+func TestFlushCancelled(t *testing.T) {
+	logger, writer := newLogger(t, WithQueueSize(1))
+
+	logger.Info("0")
+	<-writer.started
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// the marker is queued but not processed
+	if err := logger.Flush(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Flush() returned %v, want %v", err, context.Canceled)
+	}
+	// the queue is full
+	if err := logger.Flush(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Flush() returned %v, want %v", err, context.Canceled)
+	}
+	writer.open()
+	if err := logger.Flush(context.Background()); err != nil {
+		t.Errorf("Flush() returned %v", err)
+	}
+}
+
+func TestCloseDrains(t *testing.T) {
+	logger, writer := newLogger(t, WithQueueSize(200))
+	for i := 0; i < 100; i++ {
+		logger.Infof("%d", i)
+	}
+	writer.open()
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(writer.messages()); got != 100 {
+		t.Errorf("got %d messages after Close, want 100", got)
+	}
+
+	logger.Info("after")
+	if got := logger.Dropped(); got != 1 {
+		t.Errorf("Dropped() = %d after Close, want 1", got)
+	}
+	if err := logger.Close(); err != nil {
+		t.Errorf("the second Close returned %v", err)
+	}
+	if strings.Contains(writer.String(), "after") {
+		t.Errorf("an entry logged after Close was written")
+	}
+}
+
+func TestReportDropped(t *testing.T) {
+	logger, writer := newLogger(t, WithQueueSize(1), WithPolicy(DropNewest), WithReportInterval(10*time.Millisecond))
+
+	logger.Info("0")
+	<-writer.started
+	logger.Info("1")
+	logger.Info("2")
+	logger.Info("3")
+	writer.open()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(writer.String(), "dropped") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !strings.Contains(writer.String(), `level=warn msg="dropped log entries" count=2`) {
+		t.Errorf("the dropped entries were not reported:\n%s", writer.String())
+	}
+	if got := logger.Dropped(); got != 2 {
+		t.Errorf("Dropped() = %d, want 2", got)
+	}
+	// reports only cover the entries dropped since the previous one
+	time.Sleep(50 * time.Millisecond)
+	if got := strings.Count(writer.String(), "dropped"); got != 1 {
+		t.Errorf("got %d reports, want 1", got)
+	}
+}
+
+// TestTimeOfCall checks that queued entries report the time of the call,
+// not the time at which they are written.
+func TestTimeOfCall(t *testing.T) {
+	logger, writer := newLogger(t)
+
+	logger.Info("0")
+	<-writer.started
+	called := time.Now()
+	logger.Info("1")
+	time.Sleep(50 * time.Millisecond)
+	written := time.Now()
+	writer.open()
+	if err := logger.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	times := regexp.MustCompile(`time=(\S+)`).FindAllStringSubmatch(writer.String(), -1)
+	if len(times) != 2 {
+		t.Fatalf("got %d entries, want 2:\n%s", len(times), writer.String())
+	}
+	logged, err := time.Parse(time.RFC3339Nano, times[1][1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logged.Before(called) || !logged.Before(written) {
+		t.Errorf("the entry reports %v, not the time of the call (%v)", logged, called)
+	}
+}
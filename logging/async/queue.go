@@ -0,0 +1,258 @@
+package async
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dihedron/go-log-facade/logging"
+)
+
+// Policy is what happens when an entry is logged while the queue is full.
+type Policy uint8
+
+const (
+	// Block waits until there is room in the queue.
+	Block Policy = iota
+	// DropNewest discards the entry being logged.
+	DropNewest
+	// DropOldest discards the oldest entry in the queue to make room.
+	DropOldest
+	// DropBelow discards the entry being logged if it is below the
+	// threshold level, and waits until there is room otherwise.
+	DropBelow
+)
+
+type config struct {
+	size      int
+	policy    Policy
+	threshold logging.Level
+	interval  time.Duration
+}
+
+// Option is a functional option for configuring an asynchronous Logger
+// or Writer.
+type Option func(*config)
+
+// WithQueueSize sets the maximum number of entries waiting to be written;
+// the default is 1024.
+func WithQueueSize(size int) Option {
+	return func(c *config) {
+		c.size = size
+	}
+}
+
+// WithPolicy sets what happens when the queue is full; the default is Block.
+func WithPolicy(policy Policy) Option {
+	return func(c *config) {
+		c.policy = policy
+	}
+}
+
+// WithDropBelow sets the DropBelow policy, discarding entries below the
+// given level when the queue is full.
+func WithDropBelow(level logging.Level) Option {
+	return func(c *config) {
+		c.policy = DropBelow
+		c.threshold = level
+	}
+}
+
+// WithReportInterval sets how often the number of dropped entries, if
+// any, is reported as a warning through the wrapped Logger; the default
+// is one minute, and zero disables reporting. Writers never report.
+func WithReportInterval(interval time.Duration) Option {
+	return func(c *config) {
+		c.interval = interval
+	}
+}
+
+func newConfig(options ...Option) *config {
+	c := &config{
+		size:     1024,
+		policy:   Block,
+		interval: time.Minute,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	if c.size < 1 {
+		c.size = 1
+	}
+	return c
+}
+
+// item is an element of the queue: either an entry to be replayed onto a
+// Logger, a chunk of data to be written, or a flush marker.
+type item struct {
+	logger  logging.Logger
+	entry   *logging.Entry
+	data    []byte
+	flushed chan struct{}
+}
+
+// queue is a bounded queue of items, drained by a background goroutine.
+type queue struct {
+	items     chan item
+	policy    Policy
+	threshold logging.Level
+	process   func(item)
+	lock      sync.RWMutex
+	closed    bool
+	dropped   atomic.Uint64
+	reporting sync.Mutex
+	reported  uint64
+	report    func(dropped uint64)
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+func newQueue(c *config, process func(item), report func(dropped uint64)) *queue {
+	q := &queue{
+		items:     make(chan item, c.size),
+		policy:    c.policy,
+		threshold: c.threshold,
+		process:   process,
+		report:    report,
+		stop:      make(chan struct{}),
+	}
+	q.wg.Add(1)
+	go q.drain()
+	if report != nil && c.interval > 0 {
+		q.wg.Add(1)
+		go q.reportEvery(c.interval)
+	}
+	return q
+}
+
+// push adds an item to the queue, applying the overflow policy; items
+// pushed after the queue has been closed are dropped.
+func (q *queue) push(i item, level logging.Level) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	if q.closed {
+		q.dropped.Add(1)
+		return
+	}
+	select {
+	case q.items <- i:
+		return
+	default:
+	}
+	switch q.policy {
+	case DropNewest:
+		q.dropped.Add(1)
+	case DropBelow:
+		if level < q.threshold {
+			q.dropped.Add(1)
+			return
+		}
+		q.items <- i
+	case DropOldest:
+		pending := []item{i}
+		for len(pending) > 0 {
+			select {
+			case q.items <- pending[0]:
+				pending = pending[1:]
+				continue
+			default:
+			}
+			select {
+			case old := <-q.items:
+				if old.flushed != nil {
+					// never drop flush markers: queue them again behind
+					// the item, so that their waiters are only released
+					// by drain, once the items before them are written
+					pending = append(pending, old)
+				} else {
+					q.dropped.Add(1)
+				}
+			default:
+			}
+		}
+	default:
+		q.items <- i
+	}
+}
+
+// flush waits until all the items pushed so far have been processed, or
+// until the context is done.
+func (q *queue) flush(ctx context.Context) error {
+	flushed := make(chan struct{})
+	q.lock.RLock()
+	if q.closed {
+		q.lock.RUnlock()
+		return nil
+	}
+	select {
+	case q.items <- item{flushed: flushed}:
+		q.lock.RUnlock()
+	case <-ctx.Done():
+		q.lock.RUnlock()
+		return ctx.Err()
+	}
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close stops accepting items and waits until the queue has been drained;
+// it returns false if the queue was already closed.
+func (q *queue) close() bool {
+	q.lock.Lock()
+	if q.closed {
+		q.lock.Unlock()
+		return false
+	}
+	q.closed = true
+	close(q.items)
+	close(q.stop)
+	q.lock.Unlock()
+	q.wg.Wait()
+	q.reportDropped()
+	return true
+}
+
+func (q *queue) drain() {
+	defer q.wg.Done()
+	for i := range q.items {
+		if i.flushed != nil {
+			close(i.flushed)
+			continue
+		}
+		q.process(i)
+	}
+}
+
+func (q *queue) reportEvery(interval time.Duration) {
+	defer q.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			q.reportDropped()
+		case <-q.stop:
+			return
+		}
+	}
+}
+
+// reportDropped reports the number of entries dropped since the last report.
+func (q *queue) reportDropped() {
+	if q.report == nil {
+		return
+	}
+	q.reporting.Lock()
+	dropped := q.dropped.Load()
+	delta := dropped - q.reported
+	q.reported = dropped
+	q.reporting.Unlock()
+	if delta > 0 {
+		q.report(delta)
+	}
+}
@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// CallerKey is the key under which adapters that have no native notion of
+// caller report it as a dedicated field.
+const CallerKey = "caller"
+
+// Caller configures how adapters report the location of logging calls;
+// flags can be combined, e.g. CallerShort|CallerFunction.
+type Caller uint8
+
+const (
+	// CallerOff disables caller reporting.
+	CallerOff Caller = 0
+	// CallerShort reports the file as its directory and name, e.g.
+	// "stream/stream.go:42".
+	CallerShort Caller = 1 << 0
+	// CallerFull reports the full path of the file.
+	CallerFull Caller = 1 << 1
+	// CallerFunction reports the fully qualified name of the function.
+	CallerFunction Caller = 1 << 2
+)
+
+// Frame returns the frame of the given program counter, as returned by
+// CallerPC, with its file path shortened for CallerShort, or removed
+// unless CallerFull is set, and its function removed unless
+// CallerFunction is set. It returns a zero frame for CallerOff or a zero
+// program counter.
+func (c Caller) Frame(pc uintptr) runtime.Frame {
+	if c == CallerOff || pc == 0 {
+		return runtime.Frame{}
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	switch {
+	case c&CallerFull != 0:
+	case c&CallerShort != 0:
+		frame.File = trimPath(frame.File)
+	default:
+		frame.File, frame.Line = "", 0
+	}
+	if c&CallerFunction == 0 {
+		frame.Function = ""
+	}
+	return frame
+}
+
+// FormatFrame renders a frame as "file:line function", leaving out the
+// parts that are empty; it returns an empty string for a zero frame.
+func FormatFrame(frame runtime.Frame) string {
+	location := ""
+	if frame.File != "" {
+		location = frame.File + ":" + strconv.Itoa(frame.Line)
+	}
+	if frame.Function != "" {
+		if location != "" {
+			location += " "
+		}
+		location += frame.Function
+	}
+	return location
+}
+
+// CallerPC returns the program counter of the function skip frames above
+// the caller of CallerPC, e.g. CallerPC(1) in a logging method returns the
+// location of the call to the logging method, or zero if there is none.
+func CallerPC(skip int) uintptr {
+	var pcs [1]uintptr
+	if runtime.Callers(skip+2, pcs[:]) == 0 {
+		return 0
+	}
+	return pcs[0]
+}
+
+// trimPath returns the last directory and the name of the file.
+func trimPath(path string) string {
+	i := strings.LastIndexByte(path, '/')
+	if i < 0 {
+		return path
+	}
+	if j := strings.LastIndexByte(path[:i], '/'); j >= 0 {
+		return path[j+1:]
+	}
+	return path
+}
+
+// CallerLogger is implemented by Loggers that report the location of the
+// logging calls, as all the adapters and wrappers in this module do.
+type CallerLogger interface {
+	Logger
+	// AddCallerSkip returns a child Logger that reports as caller the
+	// function skip frames further up the stack, e.g. so that helper
+	// functions wrapping logging calls report their own callers.
+	AddCallerSkip(skip int) Logger
+	// WithCallerPC returns a child Logger that reports the given program
+	// counter, as returned by CallerPC, as the caller of all its logging
+	// calls; wrappers use it to write entries with the original location.
+	WithCallerPC(pc uintptr) Logger
+}
+
+// AddCallerSkip returns a Logger that reports as caller the function skip
+// frames further up the stack, if the given Logger is a CallerLogger, or
+// the Logger itself otherwise.
+func AddCallerSkip(logger Logger, skip int) Logger {
+	if c, ok := logger.(CallerLogger); ok {
+		return c.AddCallerSkip(skip)
+	}
+	return logger
+}
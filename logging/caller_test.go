@@ -0,0 +1,41 @@
+package logging_test
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/dihedron/go-log-facade/logging"
+)
+
+func TestCallerFrame(t *testing.T) {
+	if logging.CallerShort != 1 || logging.CallerFull != 2 || logging.CallerFunction != 4 {
+		t.Fatalf("the Caller flags are %d, %d and %d, want 1, 2 and 4", logging.CallerShort, logging.CallerFull, logging.CallerFunction)
+	}
+	pc := logging.CallerPC(0)
+	_, file, line, _ := runtime.Caller(0)
+	line--
+	function := "github.com/dihedron/go-log-facade/logging_test.TestCallerFrame"
+
+	tests := []struct {
+		caller         logging.Caller
+		file, function string
+		line           int
+	}{
+		{logging.CallerOff, "", "", 0},
+		{logging.CallerShort, "logging/caller_test.go", "", line},
+		{logging.CallerFull, file, "", line},
+		{logging.CallerFunction, "", function, 0},
+		{logging.CallerShort | logging.CallerFunction, "logging/caller_test.go", function, line},
+		{logging.CallerFull | logging.CallerShort, file, "", line},
+	}
+	for _, test := range tests {
+		frame := test.caller.Frame(pc)
+		if frame.File != test.file || frame.Line != test.line || frame.Function != test.function {
+			t.Errorf("Caller(%d).Frame() = %s:%d %s, want %s:%d %s", test.caller, frame.File, frame.Line, frame.Function, test.file, test.line, test.function)
+		}
+	}
+	if got := logging.FormatFrame((logging.CallerShort | logging.CallerFunction).Frame(pc)); !strings.HasPrefix(got, "logging/caller_test.go:") || !strings.HasSuffix(got, " "+function) {
+		t.Errorf("FormatFrame() = %q", got)
+	}
+}
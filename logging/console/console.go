@@ -14,13 +14,13 @@ const (
 )
 
 // NewLogger returns a stream.Logger writing to either
-// StdOut or StdErr.
-func NewLogger(where Where) *stream.Logger {
+// StdOut or StdErr, configured with the given options.
+func NewLogger(where Where, options ...stream.Option) *stream.Logger {
 	switch where {
 	case StdOut:
-		return stream.NewLogger(os.Stdout)
+		return stream.NewLogger(os.Stdout, options...)
 	case StdErr:
-		return stream.NewLogger(os.Stderr)
+		return stream.NewLogger(os.Stderr, options...)
 	}
 	return nil
 }
@@ -0,0 +1,224 @@
+package dedup
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dihedron/go-log-facade/logging"
+)
+
+// Timer is a timer scheduled by a Clock.
+type Timer interface {
+	Stop() bool
+}
+
+// Clock schedules the flushing of pending summaries; it can be replaced
+// with a fake one in tests.
+type Clock interface {
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+type realClock struct{}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+type config struct {
+	timeout time.Duration
+	clock   Clock
+}
+
+// Option is a functional option for configuring a deduplicating Logger.
+type Option func(*config)
+
+// WithTimeout sets how long after the first repetition the summary is
+// written, if no different message arrives in the meantime; the default
+// is 30 seconds, zero waits for a different message or an explicit Flush.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+	}
+}
+
+// WithClock sets the Clock used to schedule the timeout; the default
+// uses time.AfterFunc.
+func WithClock(clock Clock) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
+
+// ids generates the identities of the Loggers whose messages differ from
+// their parents'.
+var ids atomic.Uint64
+
+// state holds the last message written by a Logger and its children, the
+// identity of the Logger that wrote it, and how many times it has been
+// repeated since.
+type state struct {
+	config     *config
+	lock       sync.Mutex
+	id         uint64
+	logger     logging.Logger
+	level      logging.Level
+	key        string
+	pc         uintptr
+	repeated   int
+	timer      Timer
+	generation uint64
+}
+
+// Logger is a logger that collapses consecutive identical messages, i.e.
+// messages with the same level, format and arguments written through the
+// same Logger, into a single line followed by "last message repeated N
+// times"; the summary is written at the level of the repeated message
+// when a different message arrives, when the timeout expires, or when
+// the Logger is flushed. Panic and fatal entries are never collapsed.
+type Logger struct {
+	logging.Wrapper
+	state *state
+	id    uint64
+}
+
+// NewLogger returns a deduplicating Logger wrapping the given Logger.
+func NewLogger(logger logging.Logger, options ...Option) *Logger {
+	c := &config{
+		timeout: 30 * time.Second,
+		clock:   realClock{},
+	}
+	for _, option := range options {
+		option(c)
+	}
+	l := &Logger{state: &state{config: c}, id: ids.Add(1)}
+	l.Wrapper = logging.NewWrapper(logger, l, l.derive)
+	return l
+}
+
+// With returns a child Logger adding the given key/value pairs; since they
+// make its messages different from its parent's, it has its own identity.
+func (l *Logger) With(keysAndValues ...interface{}) logging.Logger {
+	child := l.Wrapper.With(keysAndValues...).(*Logger)
+	child.id = ids.Add(1)
+	return child
+}
+
+// Named returns a child Logger with the given name; since it makes its
+// messages different from its parent's, it has its own identity.
+func (l *Logger) Named(name string) logging.Logger {
+	child := l.Wrapper.Named(name).(*Logger)
+	child.id = ids.Add(1)
+	return child
+}
+
+// derive returns a child Logger sharing the same state and identity, so
+// that the messages of the children differing only in how they report the
+// caller are collapsed with their parent's.
+func (l *Logger) derive(w logging.Wrapper) logging.Logger {
+	child := &Logger{state: l.state, id: l.id}
+	child.Wrapper = w.Bind(child, child.derive)
+	return child
+}
+
+// Handle writes the entry, unless it repeats the last one.
+func (l *Logger) Handle(entry *logging.Entry) {
+	s := l.state
+	s.lock.Lock()
+	if entry.Level >= logging.LevelPanic {
+		s.flush()
+		s.id = 0
+		s.lock.Unlock()
+		entry.Replay(l.Unwrap())
+		return
+	}
+	key := keyOf(entry)
+	if s.id == l.id && s.level == entry.Level && s.key == key {
+		s.repeated++
+		if s.repeated == 1 && s.config.timeout > 0 {
+			generation := s.generation
+			s.timer = s.config.clock.AfterFunc(s.config.timeout, func() {
+				s.lock.Lock()
+				defer s.lock.Unlock()
+				if s.generation == generation {
+					s.flush()
+				}
+			})
+		}
+		s.lock.Unlock()
+		return
+	}
+	// write under the lock, so that the summary always precedes the
+	// different message that caused it
+	defer s.lock.Unlock()
+	s.flush()
+	s.id = l.id
+	s.logger = l.Unwrap()
+	s.level = entry.Level
+	s.key = key
+	s.pc = entry.PC
+	entry.Replay(l.Unwrap())
+}
+
+// Flush writes the summary of the pending repetitions, if any.
+func (l *Logger) Flush() {
+	l.state.lock.Lock()
+	defer l.state.lock.Unlock()
+	l.state.flush()
+}
+
+// Sync writes the pending summary and flushes the wrapped Logger, if it
+// supports it.
+func (l *Logger) Sync() error {
+	l.Flush()
+	if syncer, ok := l.Unwrap().(interface{ Sync() error }); ok {
+		return syncer.Sync()
+	}
+	return nil
+}
+
+// Close writes the pending summary and closes the wrapped Logger, if it
+// supports it.
+func (l *Logger) Close() error {
+	l.Flush()
+	if closer, ok := l.Unwrap().(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// flush writes the summary, if there are repetitions, and cancels the
+// timer; it must be called with the lock held.
+func (s *state) flush() {
+	s.generation++
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.repeated == 0 {
+		return
+	}
+	summary := &logging.Entry{
+		Time:   time.Now(),
+		Level:  s.level,
+		Style:  logging.StylePrintf,
+		Format: "last message repeated %d times",
+		Args:   []interface{}{s.repeated},
+		PC:     s.pc,
+	}
+	s.repeated = 0
+	summary.Replay(s.logger)
+}
+
+// keyOf returns a string identifying the message of the entry, including
+// its style and key/value pairs.
+func keyOf(entry *logging.Entry) string {
+	switch entry.Style {
+	case logging.StylePrintf:
+		return "f" + entry.Format + "\x00" + entry.Message()
+	case logging.StylePrintw:
+		return "w" + entry.Format + "\x00" + logging.FormatFields(entry.Args...)
+	}
+	return "p" + entry.Message()
+}
@@ -0,0 +1,119 @@
+package dedup
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dihedron/go-log-facade/logging"
+	"github.com/dihedron/go-log-facade/logging/stream"
+)
+
+// fakeClock records the scheduled functions, which the tests run by hand.
+type fakeClock struct {
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	f       func()
+	stopped bool
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	timer := &fakeTimer{f: f}
+	c.timers = append(c.timers, timer)
+	return timer
+}
+
+func (t *fakeTimer) Stop() bool {
+	stopped := t.stopped
+	t.stopped = true
+	return !stopped
+}
+
+// fire runs the functions of the timers that have not been stopped.
+func (c *fakeClock) fire() {
+	timers := c.timers
+	c.timers = nil
+	for _, timer := range timers {
+		if !timer.stopped {
+			timer.stopped = true
+			timer.f()
+		}
+	}
+}
+
+// uncomparable is a Logger whose dynamic type cannot be compared with ==.
+type uncomparable struct {
+	*stream.Logger
+	tags []string
+}
+
+func newLogger(clock Clock) (*Logger, *bytes.Buffer) {
+	buffer := &bytes.Buffer{}
+	inner := stream.NewLogger(buffer, stream.WithEncoder(&stream.LogfmtEncoder{}), stream.WithCaller(logging.CallerOff))
+	logger := NewLogger(uncomparable{Logger: inner}, WithClock(clock), WithTimeout(time.Second))
+	logger.SetLevel(logging.LevelInfo)
+	return logger, buffer
+}
+
+func messages(buffer *bytes.Buffer) []string {
+	var result []string
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		if i := strings.Index(line, " level="); i >= 0 {
+			line = line[i+1:]
+		}
+		result = append(result, line)
+	}
+	return result
+}
+
+func TestRepetitionsAreCollapsed(t *testing.T) {
+	clock := &fakeClock{}
+	logger, buffer := newLogger(clock)
+
+	for i := 0; i < 3; i++ {
+		logger.Infow("message", "key", "value")
+	}
+	logger.Warn("other")
+	for i := 0; i < 2; i++ {
+		logger.Warn("other")
+	}
+	clock.fire()
+	logger.Warn("other")
+	logger.Sync()
+
+	want := []string{
+		`level=info msg=message key=value`,
+		`level=info msg="last message repeated 2 times"`,
+		`level=warn msg=other`,
+		`level=warn msg="last message repeated 2 times"`,
+		`level=warn msg="last message repeated 1 times"`,
+	}
+	if got := messages(buffer); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestChildrenAreDistinct(t *testing.T) {
+	clock := &fakeClock{}
+	logger, buffer := newLogger(clock)
+	child := logger.With("key", "value")
+
+	logger.Info("message")
+	child.Info("message")
+	logging.AddCallerSkip(child, 1).Info("message")
+	logger.Named("child").Info("message")
+	logger.Flush()
+
+	want := []string{
+		`level=info msg=message`,
+		`level=info msg=message key=value`,
+		`level=info msg="last message repeated 1 times" key=value`,
+		`level=info logger=child msg=message`,
+	}
+	if got := messages(buffer); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,327 @@
+package logging
+
+import (
+	"time"
+)
+
+// Style identifies the family of logging methods that produced an Entry.
+type Style uint8
+
+const (
+	// StylePrint is used by Trace, Debug, Info etc.
+	StylePrint Style = iota
+	// StylePrintf is used by Tracef, Debugf, Infof etc.
+	StylePrintf
+	// StylePrintw is used by Tracew, Debugw, Infow etc.
+	StylePrintw
+)
+
+// Entry is a single call to one of a Logger's logging methods, captured
+// so that it can be inspected, buffered and replayed onto another Logger.
+type Entry struct {
+	// Time is the time of the call.
+	Time time.Time
+	// Level is the level of the method that was called.
+	Level Level
+	// Style is the family of the method that was called.
+	Style Style
+	// Format is the format for StylePrintf, the message for StylePrintw
+	// and empty for StylePrint.
+	Format string
+	// Args holds the arguments, or the key/value pairs for StylePrintw.
+	Args []interface{}
+	// PC is the program counter of the call, used to report its location,
+	// or zero if unknown.
+	PC uintptr
+	// Stack is the stack trace of the call, if the Logger that captured
+	// the entry writes to a StackLogger adding stack traces at its level.
+	Stack StackTrace
+}
+
+// Message returns the message of the entry, formatted as the text loggers
+// would, but without the key/value pairs.
+func (e *Entry) Message() string {
+	switch e.Style {
+	case StylePrintf:
+		return Sprintf(e.Format, e.Args...)
+	case StylePrintw:
+		return e.Format
+	}
+	return Sprint(e.Args...)
+}
+
+// TimeLogger is implemented by the Loggers that can write entries with a
+// given time, so that Loggers writing entries later, e.g. after buffering
+// them, can report the time of the call instead of the current one.
+type TimeLogger interface {
+	// WithTime returns a child Logger that reports the given time as the
+	// time of all its entries; it shares the logging level of its parent.
+	WithTime(t time.Time) Logger
+}
+
+// Replay calls the logging method of the given Logger that matches the
+// level and style of the entry, reporting the time, the location and the
+// stack trace of the original call if the Logger is a TimeLogger, a
+// CallerLogger and a StackLogger respectively; note that replaying
+// LevelPanic and LevelFatal entries panics and exits respectively.
+func (e *Entry) Replay(logger Logger) {
+	if !e.Time.IsZero() {
+		if t, ok := logger.(TimeLogger); ok {
+			logger = t.WithTime(e.Time)
+		}
+	}
+	if e.PC != 0 {
+		if c, ok := logger.(CallerLogger); ok {
+			logger = c.WithCallerPC(e.PC)
+		}
+	}
+	if e.Stack != nil {
+		if s, ok := logger.(StackLogger); ok {
+			logger = s.WithStack(e.Stack)
+		}
+	}
+	switch e.Style {
+	case StylePrint:
+		switch e.Level {
+		case LevelTrace:
+			logger.Trace(e.Args...)
+		case LevelDebug:
+			logger.Debug(e.Args...)
+		case LevelInfo:
+			logger.Info(e.Args...)
+		case LevelWarn:
+			logger.Warn(e.Args...)
+		case LevelError:
+			logger.Error(e.Args...)
+		case LevelPanic:
+			logger.Panic(e.Args...)
+		case LevelFatal:
+			logger.Fatal(e.Args...)
+		}
+	case StylePrintf:
+		switch e.Level {
+		case LevelTrace:
+			logger.Tracef(e.Format, e.Args...)
+		case LevelDebug:
+			logger.Debugf(e.Format, e.Args...)
+		case LevelInfo:
+			logger.Infof(e.Format, e.Args...)
+		case LevelWarn:
+			logger.Warnf(e.Format, e.Args...)
+		case LevelError:
+			logger.Errorf(e.Format, e.Args...)
+		case LevelPanic:
+			logger.Panicf(e.Format, e.Args...)
+		case LevelFatal:
+			logger.Fatalf(e.Format, e.Args...)
+		}
+	case StylePrintw:
+		switch e.Level {
+		case LevelTrace:
+			logger.Tracew(e.Format, e.Args...)
+		case LevelDebug:
+			logger.Debugw(e.Format, e.Args...)
+		case LevelInfo:
+			logger.Infow(e.Format, e.Args...)
+		case LevelWarn:
+			logger.Warnw(e.Format, e.Args...)
+		case LevelError:
+			logger.Errorw(e.Format, e.Args...)
+		case LevelPanic:
+			logger.Panicw(e.Format, e.Args...)
+		case LevelFatal:
+			logger.Fatalw(e.Format, e.Args...)
+		}
+	}
+}
+
+// Handler processes the entries captured by a Dispatcher.
+type Handler interface {
+	// Enabled returns whether entries at the given level are handled.
+	Enabled(level Level) bool
+	// Handle processes an entry; LevelPanic entries must cause a panic
+	// and LevelFatal entries must exit the application, usually by
+	// replaying them onto a Logger, unless the Handler was derived with
+	// WithoutExit.
+	Handle(entry *Entry)
+}
+
+// Dispatcher implements all the logging methods of the Logger interface
+// by capturing each call into an Entry and passing it to its Handler. It
+// is embedded in Wrapper, which provides the other methods of Loggers
+// decorating a single Logger; Loggers decorating several ones embed it
+// directly and derive the Dispatcher of their children with WithHandler.
+type Dispatcher struct {
+	Handler Handler
+	// CallerSkip is the number of additional frames to skip when looking
+	// for the caller of the logging methods.
+	CallerSkip int
+	// CallerPC, if not zero, is reported as the caller of all the logging
+	// methods.
+	CallerPC uintptr
+	// Stack, if not nil, is the stack trace of all the entries at or above
+	// the stack level of the Handler, which is otherwise captured if the
+	// Handler is a StackLogger.
+	Stack StackTrace
+	// NoExit, if set, makes the Fatal methods return instead of exiting
+	// when LevelFatal is disabled.
+	NoExit bool
+	// Time, if not zero, is reported as the time of all the entries.
+	Time time.Time
+}
+
+// WithHandler returns a copy of the Dispatcher, with the same caller
+// settings, passing entries to the given Handler.
+func (d Dispatcher) WithHandler(handler Handler) Dispatcher {
+	d.Handler = handler
+	return d
+}
+
+func (d Dispatcher) dispatch(level Level, style Style, format string, args []interface{}) {
+	if d.Handler.Enabled(level) {
+		if style == StylePrintw {
+			args = captureStackTrace(args)
+		}
+		pc := d.CallerPC
+		if pc == 0 {
+			// skip dispatch and the logging method
+			pc = CallerPC(2 + d.CallerSkip)
+		}
+		var stack StackTrace
+		if s, ok := d.Handler.(StackLogger); ok && level >= s.StackLevel() {
+			stack = d.Stack
+			if stack == nil {
+				stack = CaptureStackTrace()
+			}
+		}
+		t := d.Time
+		if t.IsZero() {
+			t = time.Now()
+		}
+		d.Handler.Handle(&Entry{
+			Time:   t,
+			Level:  level,
+			Style:  style,
+			Format: format,
+			Args:   args,
+			PC:     pc,
+			Stack:  stack,
+		})
+		return
+	}
+	// Panic and Fatal keep their semantics even when disabled
+	switch level {
+	case LevelPanic:
+		entry := &Entry{Style: style, Format: format, Args: args}
+		panic(entry.Message())
+	case LevelFatal:
+		if !d.NoExit {
+			Exit(1)
+		}
+	}
+}
+
+// Trace captures a message at LevelTrace level.
+func (d Dispatcher) Trace(args ...interface{}) {
+	d.dispatch(LevelTrace, StylePrint, "", args)
+}
+
+// Tracef captures a message at LevelTrace level.
+func (d Dispatcher) Tracef(format string, args ...interface{}) {
+	d.dispatch(LevelTrace, StylePrintf, format, args)
+}
+
+// Tracew captures a message with the given key/value pairs at LevelTrace level.
+func (d Dispatcher) Tracew(msg string, keysAndValues ...interface{}) {
+	d.dispatch(LevelTrace, StylePrintw, msg, keysAndValues)
+}
+
+// Debug captures a message at LevelDebug level.
+func (d Dispatcher) Debug(args ...interface{}) {
+	d.dispatch(LevelDebug, StylePrint, "", args)
+}
+
+// Debugf captures a message at LevelDebug level.
+func (d Dispatcher) Debugf(format string, args ...interface{}) {
+	d.dispatch(LevelDebug, StylePrintf, format, args)
+}
+
+// Debugw captures a message with the given key/value pairs at LevelDebug level.
+func (d Dispatcher) Debugw(msg string, keysAndValues ...interface{}) {
+	d.dispatch(LevelDebug, StylePrintw, msg, keysAndValues)
+}
+
+// Info captures a message at LevelInfo level.
+func (d Dispatcher) Info(args ...interface{}) {
+	d.dispatch(LevelInfo, StylePrint, "", args)
+}
+
+// Infof captures a message at LevelInfo level.
+func (d Dispatcher) Infof(format string, args ...interface{}) {
+	d.dispatch(LevelInfo, StylePrintf, format, args)
+}
+
+// Infow captures a message with the given key/value pairs at LevelInfo level.
+func (d Dispatcher) Infow(msg string, keysAndValues ...interface{}) {
+	d.dispatch(LevelInfo, StylePrintw, msg, keysAndValues)
+}
+
+// Warn captures a message at LevelWarn level.
+func (d Dispatcher) Warn(args ...interface{}) {
+	d.dispatch(LevelWarn, StylePrint, "", args)
+}
+
+// Warnf captures a message at LevelWarn level.
+func (d Dispatcher) Warnf(format string, args ...interface{}) {
+	d.dispatch(LevelWarn, StylePrintf, format, args)
+}
+
+// Warnw captures a message with the given key/value pairs at LevelWarn level.
+func (d Dispatcher) Warnw(msg string, keysAndValues ...interface{}) {
+	d.dispatch(LevelWarn, StylePrintw, msg, keysAndValues)
+}
+
+// Error captures a message at LevelError level.
+func (d Dispatcher) Error(args ...interface{}) {
+	d.dispatch(LevelError, StylePrint, "", args)
+}
+
+// Errorf captures a message at LevelError level.
+func (d Dispatcher) Errorf(format string, args ...interface{}) {
+	d.dispatch(LevelError, StylePrintf, format, args)
+}
+
+// Errorw captures a message with the given key/value pairs at LevelError level.
+func (d Dispatcher) Errorw(msg string, keysAndValues ...interface{}) {
+	d.dispatch(LevelError, StylePrintw, msg, keysAndValues)
+}
+
+// Panic captures a message at LevelPanic level.
+func (d Dispatcher) Panic(args ...interface{}) {
+	d.dispatch(LevelPanic, StylePrint, "", args)
+}
+
+// Panicf captures a message at LevelPanic level.
+func (d Dispatcher) Panicf(format string, args ...interface{}) {
+	d.dispatch(LevelPanic, StylePrintf, format, args)
+}
+
+// Panicw captures a message with the given key/value pairs at LevelPanic level.
+func (d Dispatcher) Panicw(msg string, keysAndValues ...interface{}) {
+	d.dispatch(LevelPanic, StylePrintw, msg, keysAndValues)
+}
+
+// Fatal captures a message at LevelFatal level.
+func (d Dispatcher) Fatal(args ...interface{}) {
+	d.dispatch(LevelFatal, StylePrint, "", args)
+}
+
+// Fatalf captures a message at LevelFatal level.
+func (d Dispatcher) Fatalf(format string, args ...interface{}) {
+	d.dispatch(LevelFatal, StylePrintf, format, args)
+}
+
+// Fatalw captures a message with the given key/value pairs at LevelFatal level.
+func (d Dispatcher) Fatalw(msg string, keysAndValues ...interface{}) {
+	d.dispatch(LevelFatal, StylePrintw, msg, keysAndValues)
+}
@@ -0,0 +1,58 @@
+package logging_test
+
+import (
+	"bytes"
+	"io"
+	goslog "log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dihedron/go-log-facade/logging"
+	"github.com/dihedron/go-log-facade/logging/slog"
+	"github.com/dihedron/go-log-facade/logging/stream"
+	"github.com/dihedron/go-log-facade/logging/tee"
+)
+
+// TestReplayTime checks that replayed entries report the time of the
+// call, directly and through the wrappers, instead of the current one.
+func TestReplayTime(t *testing.T) {
+	then := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+	backends := map[string]func(io.Writer) logging.Logger{
+		"stream": func(w io.Writer) logging.Logger {
+			return stream.NewLogger(w, stream.WithEncoder(&stream.LogfmtEncoder{}))
+		},
+		"slog": func(w io.Writer) logging.Logger {
+			return slog.NewLogger(goslog.New(goslog.NewTextHandler(w, nil)))
+		},
+	}
+	all := map[string]func(logging.Logger) logging.Logger{
+		"direct": func(logger logging.Logger) logging.Logger { return logger },
+		"tee": func(logger logging.Logger) logging.Logger {
+			return tee.NewLogger(tee.Sink{Logger: logger, Level: logging.LevelTrace})
+		},
+	}
+	for name, wrap := range wrappers {
+		all[name] = wrap
+	}
+	for backend, create := range backends {
+		for name, wrap := range all {
+			t.Run(backend+"/"+name, func(t *testing.T) {
+				buffer := &bytes.Buffer{}
+				inner := create(buffer)
+				inner.SetLevel(logging.LevelTrace)
+				logger := wrap(inner)
+
+				entry := &logging.Entry{Time: then, Level: logging.LevelWarn, Style: logging.StylePrintw, Format: "message"}
+				entry.Replay(logger.With("key", "value"))
+				if closer, ok := logger.(io.Closer); ok {
+					_ = closer.Close()
+				}
+
+				if !strings.HasPrefix(buffer.String(), "time=2006-01-02T15:04:05") {
+					t.Errorf("the entry does not report the time of the call: %s", buffer.String())
+				}
+			})
+		}
+	}
+}
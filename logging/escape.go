@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Escape returns the string with line breaks, other control characters,
+// terminal escape sequences and bidirectional text overrides replaced by
+// Go-style escapes (e.g. \n, \x1b or \u202e), so that untrusted input
+// cannot forge log entries or tamper with the terminal displaying them;
+// tabs are kept. If there is nothing to escape, the string is returned
+// as is.
+func Escape(s string) string {
+	i := strings.IndexFunc(s, needsEscape)
+	if i < 0 {
+		return s
+	}
+	var buffer strings.Builder
+	buffer.Grow(len(s) + 8)
+	buffer.WriteString(s[:i])
+	for _, r := range s[i:] {
+		switch {
+		case r == '\n':
+			buffer.WriteString(`\n`)
+		case r == '\r':
+			buffer.WriteString(`\r`)
+		case r < 0x80 && needsEscape(r):
+			buffer.WriteString(`\x`)
+			buffer.WriteString(strconv.FormatUint(uint64(r)>>4, 16))
+			buffer.WriteString(strconv.FormatUint(uint64(r)&0xf, 16))
+		case needsEscape(r):
+			fmt.Fprintf(&buffer, `\u%04x`, r)
+		default:
+			buffer.WriteRune(r)
+		}
+	}
+	return buffer.String()
+}
+
+// EscapeFields returns the given key/value pairs with lazy values
+// evaluated and with keys, strings, errors and fmt.Stringers escaped as
+// per Escape, for backends that render them as text; values that need
+// no escaping keep their type.
+func EscapeFields(keysAndValues ...interface{}) []interface{} {
+	escaped := make([]interface{}, len(keysAndValues))
+	for i, value := range Resolve(keysAndValues) {
+		switch v := value.(type) {
+		case string:
+			escaped[i] = Escape(v)
+			continue
+		case error:
+			if s := v.Error(); strings.IndexFunc(s, needsEscape) >= 0 {
+				escaped[i] = Escape(s)
+				continue
+			}
+		case fmt.Stringer:
+			if s := v.String(); strings.IndexFunc(s, needsEscape) >= 0 {
+				escaped[i] = Escape(s)
+				continue
+			}
+		}
+		escaped[i] = value
+	}
+	return escaped
+}
+
+// needsEscape returns whether the rune is a control character other than
+// tab (C0, DEL or C1), a Unicode line or paragraph separator, or a
+// bidirectional embedding, override or isolate.
+func needsEscape(r rune) bool {
+	return (r < 0x20 && r != '\t') || (r >= 0x7f && r <= 0x9f) ||
+		r == 0x2028 || r == 0x2029 || (r >= 0x202a && r <= 0x202e) || (r >= 0x2066 && r <= 0x2069)
+}
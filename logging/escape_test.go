@@ -0,0 +1,94 @@
+package logging_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	golog "log"
+	"strings"
+	"testing"
+
+	"github.com/dihedron/go-log-facade/logging"
+	"github.com/dihedron/go-log-facade/logging/golang"
+	"github.com/dihedron/go-log-facade/logging/hcl"
+	"github.com/dihedron/go-log-facade/logging/stream"
+	"github.com/hashicorp/go-hclog"
+)
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"plain text", "plain text"},
+		{"tab\tkept", "tab\tkept"},
+		{"line\nbreak", `line\nbreak`},
+		{"carriage\rreturn", `carriage\rreturn`},
+		{"\x1b[31mred\x1b[0m", `\x1b[31mred\x1b[0m`},
+		{"nul\x00del\x7f", `nul\x00del\x7f`},
+		{"c1\u0085next", `c1\u0085next`},
+		{"line\u2028separator", `line\u2028separator`},
+		{"evil\u202etxt.exe", `evil\u202etxt.exe`},
+		{"isolate\u2066x\u2069", `isolate\u2066x\u2069`},
+		{"unicode àèìòù", "unicode àèìòù"},
+	}
+	for _, test := range tests {
+		if got := logging.Escape(test.input); got != test.want {
+			t.Errorf("Escape(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestEscapeFields(t *testing.T) {
+	escaped := logging.EscapeFields(
+		"key\n", "value\n",
+		"error", errors.New("error\n"),
+		"number", 42,
+		"lazy", logging.Lazy(func() interface{} { return "lazy\n" }),
+	)
+	want := []interface{}{`key\n`, `value\n`, "error", `error\n`, "number", 42, "lazy", `lazy\n`}
+	for i := range want {
+		if escaped[i] != want[i] {
+			t.Errorf("EscapeFields()[%d] = %#v, want %#v", i, escaped[i], want[i])
+		}
+	}
+}
+
+// forged is a message trying to add a fake entry to the log.
+const forged = "login failed\n2026-01-01T00:00:00Z level=info msg=\"login succeeded\" user=admin"
+
+// TestForgedLines checks that untrusted names, messages and values cannot
+// add lines to the output of the text backends.
+func TestForgedLines(t *testing.T) {
+	backends := map[string]func(io.Writer) logging.Logger{
+		"stream/text": func(w io.Writer) logging.Logger {
+			return stream.NewLogger(w, stream.WithEncoder(&stream.TextEncoder{}))
+		},
+		"stream/logfmt": func(w io.Writer) logging.Logger {
+			return stream.NewLogger(w, stream.WithEncoder(&stream.LogfmtEncoder{}))
+		},
+		"golang": func(w io.Writer) logging.Logger {
+			return golang.NewLoggerWithWriter(w, "", golog.LstdFlags)
+		},
+		"hcl": func(w io.Writer) logging.Logger {
+			return hcl.NewLogger(hclog.New(&hclog.LoggerOptions{Output: w}))
+		},
+	}
+	for name, backend := range backends {
+		t.Run(name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			logger := backend(buffer).Named("name\nforged").With("with", "value\nforged")
+			logger.SetLevel(logging.LevelInfo)
+
+			logger.Info(forged)
+			logger.Infof("%s", forged)
+			logger.Infow(forged, "key\nforged", forged, "error", errors.New(forged))
+
+			if lines := strings.Count(buffer.String(), "\n"); lines != 3 {
+				t.Errorf("got %d lines for 3 entries:\n%s", lines, buffer.String())
+			}
+			if strings.Contains(buffer.String(), "\x1b") || strings.Contains(buffer.String(), "\r") {
+				t.Errorf("control characters in the output:\n%q", buffer.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"os"
+	"sync"
+)
+
+var (
+	lock3 sync.RWMutex
+	exit  = os.Exit
+)
+
+// SetExitFunc sets the function used by the Fatal family of methods to
+// terminate the application once the message has been logged and the
+// sinks flushed; it returns the previous function, so that tests can
+// replace os.Exit and restore it afterwards.
+func SetExitFunc(f func(code int)) func(code int) {
+	lock3.Lock()
+	defer lock3.Unlock()
+	previous := exit
+	exit = f
+	return previous
+}
+
+// Exit terminates the application with the given exit code by calling
+// the current exit function (os.Exit by default).
+func Exit(code int) {
+	lock3.RLock()
+	f := exit
+	lock3.RUnlock()
+	f(code)
+}
+
+// ExitLogger is implemented by the Loggers whose Fatal family of methods
+// can be told not to terminate the application.
+type ExitLogger interface {
+	// WithoutExit returns a child Logger whose Fatal methods log the
+	// message and flush as usual, then return instead of exiting; it
+	// shares the logging level of its parent.
+	WithoutExit() Logger
+}
+
+// WithoutExit returns the child of the given Logger whose Fatal methods
+// do not exit, and true, if it is an ExitLogger; otherwise, it returns the
+// Logger itself and false. It lets Loggers that fan out to several Loggers
+// replay a fatal entry onto all of them and then exit once, without
+// affecting the Fatal calls made meanwhile by other goroutines.
+func WithoutExit(logger Logger) (Logger, bool) {
+	if e, ok := logger.(ExitLogger); ok {
+		return e.WithoutExit(), true
+	}
+	return logger, false
+}
@@ -0,0 +1,49 @@
+package logging_test
+
+import (
+	"io"
+	golog "log"
+	goslog "log/slog"
+	"testing"
+
+	"github.com/dihedron/go-log-facade/logging"
+	"github.com/dihedron/go-log-facade/logging/golang"
+	"github.com/dihedron/go-log-facade/logging/hcl"
+	"github.com/dihedron/go-log-facade/logging/slog"
+	"github.com/dihedron/go-log-facade/logging/stream"
+	"github.com/hashicorp/go-hclog"
+)
+
+// TestWithoutExitIsInherited checks that the children of a WithoutExit
+// Logger do not exit either, while the Logger it derives from still does.
+func TestWithoutExitIsInherited(t *testing.T) {
+	count := 0
+	defer logging.SetExitFunc(logging.SetExitFunc(func(int) { count++ }))
+
+	loggers := map[string]logging.Logger{
+		"noop":   &logging.NoOpLogger{},
+		"stream": stream.NewLogger(io.Discard),
+		"golang": golang.NewLoggerWithWriter(io.Discard, "", golog.LstdFlags),
+		"hcl":    hcl.NewLogger(hclog.New(&hclog.LoggerOptions{Output: io.Discard})),
+		"slog":   slog.NewLogger(goslog.New(goslog.NewTextHandler(io.Discard, nil))),
+	}
+	for name, logger := range loggers {
+		count = 0
+		child, ok := logging.WithoutExit(logger)
+		if !ok {
+			t.Errorf("%s: %T is not an ExitLogger", name, logger)
+			continue
+		}
+		child = logging.AddCallerSkip(child.With("key", "value").Named("child"), 1)
+		child.Fatal("message")
+		child.Fatalf("message %d", 1)
+		child.Fatalw("message", "key", "value")
+		if count != 0 {
+			t.Errorf("%s: the children of the WithoutExit Logger exited %d times", name, count)
+		}
+		logger.With("key", "value").Fatal("message")
+		if count != 1 {
+			t.Errorf("%s: the Logger exited %d times, want 1", name, count)
+		}
+	}
+}
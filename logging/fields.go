@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BadKey is the key used for values that have no matching key, e.g.
+// when an odd number of keys and values is provided or when a key is
+// not a string.
+const BadKey = "!BADKEY"
+
+// Field is a key/value pair attached to a structured log message.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// ToFields turns a list of alternating keys and values into a slice
+// of Fields; values without a valid (string) key are reported under
+// BadKey.
+func ToFields(keysAndValues ...interface{}) []Field {
+	fields := make([]Field, 0, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); {
+		key, ok := keysAndValues[i].(string)
+		if !ok || i == len(keysAndValues)-1 {
+			fields = append(fields, Field{Key: BadKey, Value: keysAndValues[i]})
+			i++
+			continue
+		}
+		fields = append(fields, Field{Key: key, Value: keysAndValues[i+1]})
+		i += 2
+	}
+	return fields
+}
+
+// FormatFields renders a list of alternating keys and values as a
+// space-separated sequence of key=value pairs; values containing
+// blanks, quotes, equal signs or control characters are quoted, and
+// control characters in keys are escaped.
+func FormatFields(keysAndValues ...interface{}) string {
+	var buffer strings.Builder
+	for i, field := range ToFields(keysAndValues...) {
+		if i > 0 {
+			buffer.WriteString(" ")
+		}
+		buffer.WriteString(Escape(field.Key))
+		buffer.WriteString("=")
+		buffer.WriteString(FormatValue(field.Value))
+	}
+	return buffer.String()
+}
+
+// FormatValue renders a single value the way FormatFields does, i.e.
+// using its default format and quoting it if necessary, so that line
+// breaks and control characters are always escaped.
+func FormatValue(value interface{}) string {
+	s := fmt.Sprintf("%v", resolve(value))
+	if s == "" || strings.ContainsAny(s, " \t\"=") || strings.IndexFunc(s, needsEscape) >= 0 {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -1,17 +1,266 @@
 package file
 
 import (
+	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/dihedron/go-log-facade/logging/stream"
 )
 
+type config struct {
+	truncate bool
+	mode     os.FileMode
+	mkdir    bool
+	sync     bool
+	interval time.Duration
+	signals  []os.Signal
+	options  []stream.Option
+}
+
+// Option is a functional option for configuring how a log file is
+// opened and written.
+type Option func(*config)
+
+// WithAppend appends to the log file if it already exists; this is the
+// default.
+func WithAppend() Option {
+	return func(c *config) {
+		c.truncate = false
+	}
+}
+
+// WithTruncate truncates the log file if it already exists.
+func WithTruncate() Option {
+	return func(c *config) {
+		c.truncate = true
+	}
+}
+
+// WithMode sets the permissions of the log file if it is created; the
+// default is 0644.
+func WithMode(mode os.FileMode) Option {
+	return func(c *config) {
+		c.mode = mode
+	}
+}
+
+// WithParentDirs creates the directory of the log file, and any missing
+// parents, if it does not exist.
+func WithParentDirs() Option {
+	return func(c *config) {
+		c.mkdir = true
+	}
+}
+
+// WithSyncWrites opens the log file with O_SYNC, so that every entry is
+// committed to stable storage before the logging call returns.
+func WithSyncWrites() Option {
+	return func(c *config) {
+		c.sync = true
+	}
+}
+
+// WithSyncInterval commits the log file to stable storage periodically,
+// trading some durability for performance with respect to WithSyncWrites.
+func WithSyncInterval(interval time.Duration) Option {
+	return func(c *config) {
+		c.interval = interval
+	}
+}
+
+// WithReopenSignal reopens the log file whenever the process receives
+// one of the given signals, e.g. syscall.SIGHUP sent by logrotate in its
+// postrotate script.
+func WithReopenSignal(signals ...os.Signal) Option {
+	return func(c *config) {
+		c.signals = append(c.signals, signals...)
+	}
+}
+
+// WithStreamOptions sets the options of the stream.Logger returned by New,
+// e.g. its Encoder.
+func WithStreamOptions(options ...stream.Option) Option {
+	return func(c *config) {
+		c.options = append(c.options, options...)
+	}
+}
+
+// Writer is an io.Writer writing to a log file, which is safe for
+// concurrent use, optionally synced periodically and which can be
+// reopened, e.g. after the file has been moved by an external tool.
+type Writer struct {
+	path  string
+	flags int
+	mode  os.FileMode
+	lock  sync.Mutex
+	file  *os.File
+	stop  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// NewWriter opens the log file at the given path according to the given
+// options.
+func NewWriter(path string, options ...Option) (*Writer, error) {
+	c := newConfig(options...)
+	return newWriter(path, c)
+}
+
+// New returns a stream.Logger writing to a file at the given path,
+// opened according to the given options; by default, the file is
+// appended to and created with 0644 permissions if it does not exist.
+func New(path string, options ...Option) (*stream.Logger, error) {
+	c := newConfig(options...)
+	w, err := newWriter(path, c)
+	if err != nil {
+		return nil, err
+	}
+	return stream.NewLogger(w, c.options...), nil
+}
+
 // NewLogger returns a stream.Logger writing to a file at
-// the given path.
-func NewLogger(path string) *stream.Logger {
-	file, err := os.Create(path)
+// the given path, configured with the given options; the
+// file is truncated if it exists. It returns nil if the
+// file cannot be created: use New to get the error.
+func NewLogger(path string, options ...stream.Option) *stream.Logger {
+	logger, err := New(path, WithTruncate(), WithStreamOptions(options...))
 	if err != nil {
 		return nil
 	}
-	return stream.NewLogger(file)
+	return logger
+}
+
+func newConfig(options ...Option) *config {
+	c := &config{
+		mode: 0644,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+func newWriter(path string, c *config) (*Writer, error) {
+	w := &Writer{
+		path:  path,
+		flags: os.O_WRONLY | os.O_CREATE,
+		mode:  c.mode,
+	}
+	if c.truncate {
+		w.flags |= os.O_TRUNC
+	} else {
+		w.flags |= os.O_APPEND
+	}
+	if c.sync {
+		w.flags |= os.O_SYNC
+	}
+	if c.mkdir {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("error creating log directory: %w", err)
+		}
+	}
+	file, err := os.OpenFile(path, w.flags, w.mode)
+	if err != nil {
+		return nil, fmt.Errorf("error opening log file '%s': %w", path, err)
+	}
+	w.file = file
+	w.stop = make(chan struct{})
+	if c.interval > 0 {
+		w.wg.Add(1)
+		go w.syncEvery(c.interval)
+	}
+	if len(c.signals) > 0 {
+		// register synchronously, so that no signal is missed once New returns
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, c.signals...)
+		w.wg.Add(1)
+		go w.reopenOn(signals)
+	}
+	return w, nil
+}
+
+// Write writes the data to the log file.
+func (w *Writer) Write(data []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+	return w.file.Write(data)
+}
+
+// Sync commits the log file to stable storage.
+func (w *Writer) Sync() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	return w.file.Sync()
+}
+
+// Reopen closes the log file and opens it again at the same path, creating
+// it if it has been moved or removed; entries written concurrently go either
+// to the old file or to the new one, never to both and never interleaved.
+func (w *Writer) Reopen() error {
+	// open the new file before closing the old one, so that if it fails
+	// logging goes on to the old file
+	file, err := os.OpenFile(w.path, (w.flags&^os.O_TRUNC)|os.O_APPEND, w.mode)
+	if err != nil {
+		return fmt.Errorf("error reopening log file '%s': %w", w.path, err)
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.file == nil {
+		file.Close()
+		return os.ErrClosed
+	}
+	old := w.file
+	w.file = file
+	return old.Close()
+}
+
+// Close closes the log file and stops the background goroutines, if any.
+func (w *Writer) Close() error {
+	w.lock.Lock()
+	if w.file == nil {
+		w.lock.Unlock()
+		return os.ErrClosed
+	}
+	err := w.file.Close()
+	w.file = nil
+	w.lock.Unlock()
+	close(w.stop)
+	w.wg.Wait()
+	return err
+}
+
+func (w *Writer) syncEvery(interval time.Duration) {
+	defer w.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = w.Sync()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+func (w *Writer) reopenOn(signals chan os.Signal) {
+	defer w.wg.Done()
+	defer signal.Stop(signals)
+	for {
+		select {
+		case <-signals:
+			_ = w.Reopen()
+		case <-w.stop:
+			return
+		}
+	}
 }
@@ -0,0 +1,201 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/dihedron/go-log-facade/logging"
+	"github.com/dihedron/go-log-facade/logging/stream"
+)
+
+// logTo opens the file with New, logs the message and closes it.
+func logTo(t *testing.T, path, message string, options ...Option) {
+	t.Helper()
+	options = append(options, WithStreamOptions(stream.WithEncoder(&stream.LogfmtEncoder{}), stream.WithCaller(logging.CallerOff)))
+	logger, err := New(path, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.SetLevel(logging.LevelInfo)
+	logger.Info(message)
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// messages returns the messages logged to the file, in order.
+func messages(t *testing.T, path string) []string {
+	t.Helper()
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSpace(read(t, path)), "\n") {
+		_, message, _ := strings.Cut(line, " msg=")
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+func TestNewAppendsOrTruncates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	logTo(t, path, "first")
+	logTo(t, path, "second")
+	logTo(t, path, "third", WithAppend())
+	if got := strings.Join(messages(t, path), ","); got != "first,second,third" {
+		t.Errorf("got messages %s after appending", got)
+	}
+	logTo(t, path, "fourth", WithTruncate())
+	if got := strings.Join(messages(t, path), ","); got != "fourth" {
+		t.Errorf("got messages %s after truncating", got)
+	}
+}
+
+func TestNewMode(t *testing.T) {
+	dir := t.TempDir()
+	for path, want := range map[string]os.FileMode{
+		filepath.Join(dir, "default.log"): 0644,
+		filepath.Join(dir, "private.log"): 0600,
+	} {
+		var options []Option
+		if want != 0644 {
+			options = append(options, WithMode(want))
+		}
+		logTo(t, path, "message", options...)
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("%s has mode %v, want %v", filepath.Base(path), got, want)
+		}
+	}
+}
+
+func TestNewParentDirs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a", "b", "app.log")
+	if _, err := New(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("New() without WithParentDirs returned %v, want %v", err, fs.ErrNotExist)
+	}
+	logTo(t, path, "message", WithParentDirs())
+	if got := messages(t, path); len(got) != 1 || got[0] != "message" {
+		t.Errorf("got messages %v", got)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, path string
+		options    []Option
+		want       string
+	}{
+		{"missing directory", filepath.Join(dir, "missing", "app.log"), nil, "error opening log file"},
+		{"directory", dir, nil, "error opening log file"},
+		{"parent is a file", filepath.Join(file, "app.log"), []Option{WithParentDirs()}, "error creating log directory"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logger, err := New(test.path, test.options...)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("New() returned %v, want an error containing %q", err, test.want)
+			}
+			if logger != nil {
+				t.Errorf("New() returned a Logger along with the error")
+			}
+			if w, err := NewWriter(test.path, test.options...); err == nil || w != nil {
+				t.Errorf("NewWriter() returned %v, %v", w, err)
+			}
+		})
+	}
+	if logger := NewLogger(filepath.Join(dir, "missing", "app.log")); logger != nil {
+		t.Errorf("NewLogger() returned a Logger for a missing directory")
+	}
+}
+
+// TestReopen moves the log file while lines are being written and checks
+// that, once the Writer is reopened (directly or by a signal), later lines
+// go to the new file and that no line is lost or written twice.
+func TestReopen(t *testing.T) {
+	reopens := map[string]struct {
+		options []Option
+		reopen  func(*Writer) error
+	}{
+		"Reopen": {
+			reopen: (*Writer).Reopen,
+		},
+		"WithReopenSignal": {
+			options: []Option{WithReopenSignal(syscall.SIGHUP)},
+			reopen: func(*Writer) error {
+				process, err := os.FindProcess(os.Getpid())
+				if err != nil {
+					return err
+				}
+				return process.Signal(syscall.SIGHUP)
+			},
+		},
+	}
+	for name, test := range reopens {
+		t.Run(name, func(t *testing.T) {
+			if runtime.GOOS == "windows" && len(test.options) > 0 {
+				t.Skip("signals cannot be sent on Windows")
+			}
+			dir := t.TempDir()
+			path := filepath.Join(dir, "app.log")
+			moved := filepath.Join(dir, "app.log.1")
+			w, err := NewWriter(path, test.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			const lines = 1000
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for i := 0; i < lines; i++ {
+					fmt.Fprintf(w, "%d\n", i)
+				}
+			}()
+			if err := os.Rename(path, moved); err != nil {
+				t.Fatal(err)
+			}
+			if err := test.reopen(w); err != nil {
+				t.Fatal(err)
+			}
+			<-done
+			// the signal is handled asynchronously: wait for the new file
+			deadline := time.Now().Add(5 * time.Second)
+			for !exists(path) && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			fmt.Fprintf(w, "%d\n", lines)
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			after := strings.Fields(read(t, path))
+			if len(after) == 0 || after[len(after)-1] != strconv.Itoa(lines) {
+				t.Fatalf("the last line was not written to the new file: %v", after)
+			}
+			all := append(strings.Fields(read(t, moved)), after...)
+			if len(all) != lines+1 {
+				t.Fatalf("got %d lines, want %d", len(all), lines+1)
+			}
+			for i, line := range all {
+				if line != strconv.Itoa(i) {
+					t.Fatalf("line %d is %q", i, line)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package file
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dihedron/go-log-facade/logging/stream"
+)
+
+// backupTimeFormat is the layout of the timestamp added to the names of
+// rotated files; it contains no colons, so it is valid on all platforms.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// Interval is a time boundary at which a RotatingWriter rolls over.
+type Interval uint8
+
+const (
+	// Never disables time-based rotation.
+	Never Interval = iota
+	// Hourly rotates at the beginning of every hour.
+	Hourly
+	// Daily rotates at midnight.
+	Daily
+)
+
+// next returns the first boundary after the given time, or the zero
+// time if there is none.
+func (i Interval) next(t time.Time) time.Time {
+	switch i {
+	case Hourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+	case Daily:
+		return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+// Rotation configures when a RotatingWriter rolls over and how many
+// rotated files it retains; zero values disable the corresponding
+// feature.
+type Rotation struct {
+	// MaxSize is the size in bytes above which the file is rotated.
+	MaxSize int64
+	// Interval is the time boundary at which the file is rotated.
+	Interval Interval
+	// MaxBackups is the number of rotated files to keep.
+	MaxBackups int
+	// MaxAge is the age after which rotated files are deleted.
+	MaxAge time.Duration
+	// Compress enables gzip compression of rotated files.
+	Compress bool
+}
+
+// RotatingWriter is an io.Writer that writes to a file at the given path
+// and renames it to a timestamped backup (e.g. app-2006-01-02T15-04-05.000.log)
+// whenever it grows beyond the maximum size or a time boundary is crossed.
+// Old backups are compressed and deleted in the background. It is safe
+// for concurrent use and never splits a single Write across files.
+type RotatingWriter struct {
+	path     string
+	rotation Rotation
+	lock     sync.Mutex
+	file     *os.File
+	size     int64
+	next     time.Time
+	mill     sync.Mutex
+	wg       sync.WaitGroup
+}
+
+// NewRotatingWriter opens (or creates) the file at the given path for
+// appending, rotating it according to the given policy.
+func NewRotatingWriter(path string, rotation Rotation) (*RotatingWriter, error) {
+	w := &RotatingWriter{
+		path:     path,
+		rotation: rotation,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// NewRotatingLogger returns a stream.Logger writing to a RotatingWriter,
+// configured with the given options.
+func NewRotatingLogger(path string, rotation Rotation, options ...stream.Option) (*stream.Logger, error) {
+	w, err := NewRotatingWriter(path, rotation)
+	if err != nil {
+		return nil, err
+	}
+	return stream.NewLogger(w, options...), nil
+}
+
+// Write writes the data to the current file, rotating it first if needed;
+// if the rotation fails, the data is written to the current file and the
+// rotation error is returned.
+func (w *RotatingWriter) Write(data []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+	now := time.Now()
+	if (w.rotation.MaxSize > 0 && w.size > 0 && w.size+int64(len(data)) > w.rotation.MaxSize) ||
+		(!w.next.IsZero() && !now.Before(w.next)) {
+		if err := w.rotate(now); err != nil {
+			// the data is written to the current file anyway, so that
+			// logging goes on, and rotation is retried on the next write
+			n, _ := w.file.Write(data)
+			w.size += int64(n)
+			return n, err
+		}
+	}
+	n, err := w.file.Write(data)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate forces the current file to be rotated.
+func (w *RotatingWriter) Rotate() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	return w.rotate(time.Now())
+}
+
+// Sync commits the current file to stable storage.
+func (w *RotatingWriter) Sync() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	return w.file.Sync()
+}
+
+// Close closes the current file and waits for any background cleanup
+// of rotated files to complete.
+func (w *RotatingWriter) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	err := w.file.Close()
+	w.file = nil
+	w.wg.Wait()
+	return err
+}
+
+// open opens the file for appending, creating it and its directory if
+// needed.
+func (w *RotatingWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0755); err != nil {
+		return fmt.Errorf("error creating log directory: %w", err)
+	}
+	file, err := os.OpenFile(w.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening log file '%s': %w", w.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("error reading log file info '%s': %w", w.path, err)
+	}
+	w.file = file
+	w.size = info.Size()
+	w.next = w.rotation.Interval.next(time.Now())
+	return nil
+}
+
+// rotate renames the current file to a timestamped backup, opens a new
+// one and starts the background cleanup; it must be called with the lock
+// held. If the file cannot be renamed or the new one cannot be opened,
+// the current file is kept open, so that writing can go on.
+func (w *RotatingWriter) rotate(now time.Time) error {
+	ext := filepath.Ext(w.path)
+	backup := strings.TrimSuffix(w.path, ext) + "-" + now.Format(backupTimeFormat) + ext
+	for exists(backup) || exists(backup+".gz") {
+		// never overwrite a previous backup when rotating twice within a millisecond
+		now = now.Add(time.Millisecond)
+		backup = strings.TrimSuffix(w.path, ext) + "-" + now.Format(backupTimeFormat) + ext
+	}
+	if err := os.Rename(w.path, backup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error renaming log file '%s': %w", w.path, err)
+	}
+	previous := w.file
+	if err := w.open(); err != nil {
+		// go on writing to the renamed file until a rotation succeeds
+		return err
+	}
+	err := previous.Close()
+	if w.rotation.Compress || w.rotation.MaxBackups > 0 || w.rotation.MaxAge > 0 {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			w.cleanup()
+		}()
+	}
+	if err != nil {
+		return fmt.Errorf("error closing log file '%s': %w", backup, err)
+	}
+	return nil
+}
+
+// backup is a rotated log file.
+type backup struct {
+	path string
+	time time.Time
+}
+
+// cleanup compresses rotated files and removes those exceeding the
+// maximum number of backups or age; errors are ignored, since there is
+// nowhere to report them.
+func (w *RotatingWriter) cleanup() {
+	w.mill.Lock()
+	defer w.mill.Unlock()
+
+	dir := filepath.Dir(w.path)
+	ext := filepath.Ext(w.path)
+	prefix := strings.TrimSuffix(filepath.Base(w.path), ext) + "-"
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	backups := []backup{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		timestamp := strings.TrimPrefix(name, prefix)
+		switch {
+		case strings.HasSuffix(timestamp, ext+".gz"):
+			timestamp = strings.TrimSuffix(timestamp, ext+".gz")
+		case strings.HasSuffix(timestamp, ext):
+			timestamp = strings.TrimSuffix(timestamp, ext)
+		default:
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, timestamp, time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{path: filepath.Join(dir, name), time: t})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].time.After(backups[j].time)
+	})
+
+	cutoff := time.Now().Add(-w.rotation.MaxAge)
+	for i, b := range backups {
+		if (w.rotation.MaxBackups > 0 && i >= w.rotation.MaxBackups) ||
+			(w.rotation.MaxAge > 0 && b.time.Before(cutoff)) {
+			os.Remove(b.path)
+			continue
+		}
+		if w.rotation.Compress && !strings.HasSuffix(b.path, ".gz") {
+			compress(b.path)
+		}
+	}
+}
+
+// exists returns whether a file exists at the given path.
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// compress gzips the file and removes the original.
+func compress(path string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	target, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	writer := gzip.NewWriter(target)
+	if _, err = io.Copy(writer, source); err == nil {
+		err = writer.Close()
+	}
+	if cerr := target.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,248 @@
+package file
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRotateFailureKeepsWriting makes the rename fail, since the name of
+// the backup is too long, and checks that writing goes on to the current
+// file.
+func TestRotateFailureKeepsWriting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), strings.Repeat("a", 250)+".log")
+	w, err := NewRotatingWriter(path, Rotation{MaxSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	line := []byte("0123456789\n")
+	for i := 0; i < 3; i++ {
+		if n, _ := w.Write(line); n != len(line) {
+			t.Fatalf("write %d: wrote %d bytes, want %d", i, n, len(line))
+		}
+	}
+	if err := w.Rotate(); err == nil {
+		t.Fatal("Rotate succeeded, want a rename error")
+	}
+	if _, err := w.Write(line); err == nil {
+		t.Fatal("Write succeeded without rotating, want the rotation error")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Repeat(string(line), 4); string(data) != want {
+		t.Fatalf("file contains %q, want %q", data, want)
+	}
+}
+
+// backups returns the names of the rotated files in the directory of the
+// given path, in lexical (i.e. chronological) order.
+func backups(t *testing.T, path string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(strings.TrimSuffix(path, ".log") + "-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(matches))
+	for i, match := range matches {
+		names[i] = filepath.Base(match)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func read(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRotateOnSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := NewRotatingWriter(path, Rotation{MaxSize: 25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"0123456789\n", "abcdefghij\n", "ABCDEFGHIJ\n", strings.Repeat("x", 30) + "\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+
+	names := backups(t, path)
+	if len(names) != 2 {
+		t.Fatalf("got backups %v, want 2", names)
+	}
+	pattern := regexp.MustCompile(`^app-\d{4}-\d{2}-\d{2}T\d{2}-\d{2}-\d{2}\.\d{3}\.log$`)
+	for _, name := range names {
+		if !pattern.MatchString(name) {
+			t.Errorf("backup %s does not match %s", name, pattern)
+		}
+	}
+	dir := filepath.Dir(path)
+	// a write is never split and an oversized one goes to a file of its own
+	for i, want := range []string{"0123456789\nabcdefghij\n", "ABCDEFGHIJ\n"} {
+		if got := read(t, filepath.Join(dir, names[i])); got != want {
+			t.Errorf("backup %d contains %q, want %q", i, got, want)
+		}
+	}
+	if got, want := read(t, path), strings.Repeat("x", 30)+"\n"; got != want {
+		t.Errorf("file contains %q, want %q", got, want)
+	}
+}
+
+func TestRotateOnInterval(t *testing.T) {
+	now := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+	for interval, want := range map[Interval]time.Time{
+		Never:  {},
+		Hourly: time.Date(2006, 1, 2, 16, 0, 0, 0, time.UTC),
+		Daily:  time.Date(2006, 1, 3, 0, 0, 0, 0, time.UTC),
+	} {
+		if got := interval.next(now); !got.Equal(want) {
+			t.Errorf("Interval(%d).next() = %v, want %v", interval, got, want)
+		}
+	}
+
+	for _, interval := range []Interval{Hourly, Daily} {
+		path := filepath.Join(t.TempDir(), "app.log")
+		w, err := NewRotatingWriter(path, Rotation{Interval: interval})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte("before\n"))
+		if names := backups(t, path); len(names) != 0 {
+			t.Errorf("interval %d: rotated before the boundary: %v", interval, names)
+		}
+		// pretend the boundary has just been crossed
+		w.lock.Lock()
+		w.next = time.Now().Add(-time.Second)
+		w.lock.Unlock()
+		w.Write([]byte("after\n"))
+		if !w.next.After(time.Now()) {
+			t.Errorf("interval %d: next boundary %v is not in the future", interval, w.next)
+		}
+		w.Close()
+
+		names := backups(t, path)
+		if len(names) != 1 {
+			t.Fatalf("interval %d: got backups %v, want 1", interval, names)
+		}
+		if got := read(t, filepath.Join(filepath.Dir(path), names[0])); got != "before\n" {
+			t.Errorf("interval %d: backup contains %q", interval, got)
+		}
+		if got := read(t, path); got != "after\n" {
+			t.Errorf("interval %d: file contains %q", interval, got)
+		}
+	}
+}
+
+func TestRotateNeverOverwritesBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := NewRotatingWriter(path, Rotation{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		w.Write([]byte(strconv.Itoa(i)))
+		if err := w.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+
+	names := backups(t, path)
+	if len(names) != 5 {
+		t.Fatalf("got backups %v, want 5", names)
+	}
+	for i, name := range names {
+		if got := read(t, filepath.Join(filepath.Dir(path), name)); got != strconv.Itoa(i) {
+			t.Errorf("backup %s contains %q, want %d", name, got, i)
+		}
+	}
+}
+
+func TestRotatePrunesBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	// backups left by previous runs, the oldest beyond the maximum age
+	now := time.Now()
+	old := []time.Time{now.Add(-72 * time.Hour), now.Add(-2 * time.Hour), now.Add(-time.Hour)}
+	for _, t0 := range old {
+		name := filepath.Join(dir, "app-"+t0.Format(backupTimeFormat)+".log")
+		if err := os.WriteFile(name, []byte("old\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	unrelated := filepath.Join(dir, "app-unrelated.log")
+	os.WriteFile(unrelated, nil, 0644)
+
+	w, err := NewRotatingWriter(path, Rotation{MaxBackups: 3, MaxAge: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		w.Write([]byte("new\n"))
+		if err := w.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+
+	names := backups(t, path)
+	kept := "app-" + old[2].Format(backupTimeFormat) + ".log"
+	if len(names) != 4 || names[0] != kept || names[3] != "app-unrelated.log" {
+		t.Fatalf("got backups %v, want the 3 newest and the unrelated file", names)
+	}
+	for _, name := range names[1:3] {
+		if got := read(t, filepath.Join(dir, name)); got != "new\n" {
+			t.Errorf("backup %s contains %q", name, got)
+		}
+	}
+}
+
+func TestRotateCompresses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := NewRotatingWriter(path, Rotation{Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("compressed\n"))
+	if err := w.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	names := backups(t, path)
+	if len(names) != 1 || !strings.HasSuffix(names[0], ".log.gz") {
+		t.Fatalf("got backups %v, want a single .log.gz file", names)
+	}
+	file, err := os.Open(filepath.Join(filepath.Dir(path), names[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "compressed\n" {
+		t.Errorf("backup contains %q", data)
+	}
+}
@@ -0,0 +1,171 @@
+package fingerscrossed
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dihedron/go-log-facade/logging"
+)
+
+type config struct {
+	threshold logging.Level
+	trigger   logging.Level
+	capacity  int
+	stay      bool
+}
+
+// Option is a functional option for configuring a fingers-crossed Logger.
+type Option func(*config)
+
+// WithThreshold sets the level at or above which entries are written
+// immediately instead of being buffered; the default is LevelInfo.
+func WithThreshold(level logging.Level) Option {
+	return func(c *config) {
+		c.threshold = level
+	}
+}
+
+// WithTrigger sets the level at or above which an entry flushes the
+// buffer; the default is LevelError. Panic and fatal entries always do.
+func WithTrigger(level logging.Level) Option {
+	return func(c *config) {
+		c.trigger = level
+	}
+}
+
+// WithCapacity sets the maximum number of buffered entries per scope,
+// above which the oldest ones are discarded; the default is 100.
+func WithCapacity(capacity int) Option {
+	return func(c *config) {
+		c.capacity = capacity
+	}
+}
+
+// WithStayTriggered makes scopes write all entries through once they have
+// been triggered, until Discard is called; by default, a scope starts
+// buffering again right after flushing.
+func WithStayTriggered() Option {
+	return func(c *config) {
+		c.stay = true
+	}
+}
+
+// buffered is an entry waiting in a scope, with the Logger it must be
+// replayed onto.
+type buffered struct {
+	logger logging.Logger
+	entry  *logging.Entry
+}
+
+// scope holds the entries buffered by a Logger and its children.
+type scope struct {
+	lock      sync.Mutex
+	entries   []buffered
+	triggered bool
+}
+
+// Logger is a logger that keeps entries below a threshold level (e.g.
+// debug and trace messages) in memory, and writes them to the wrapped
+// Logger only if an entry at or above the trigger level (e.g. an error)
+// is logged in the same scope, after which the scope starts buffering
+// again (see WithStayTriggered). Entries at or above the threshold are
+// always written immediately. Since buffered entries are replayed onto
+// the wrapped Logger, its level must let them through (e.g. LevelTrace),
+// while this Logger's threshold determines what is written in the normal
+// case; buffered entries report the time of the call if the wrapped Logger
+// is a logging.TimeLogger.
+type Logger struct {
+	logging.Wrapper
+	config *config
+	scope  *scope
+}
+
+// NewLogger returns a fingers-crossed Logger wrapping the given Logger,
+// with its own scope.
+func NewLogger(logger logging.Logger, options ...Option) *Logger {
+	c := &config{
+		threshold: logging.LevelInfo,
+		trigger:   logging.LevelError,
+		capacity:  100,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	l := &Logger{config: c, scope: &scope{}}
+	l.Wrapper = logging.NewWrapper(logger, l, l.derive)
+	return l
+}
+
+// derive returns a child Logger sharing the same scope.
+func (l *Logger) derive(w logging.Wrapper) logging.Logger {
+	child := &Logger{config: l.config, scope: l.scope}
+	child.Wrapper = w.Bind(child, child.derive)
+	return child
+}
+
+// NewContext returns a copy of the context holding a new scope of the
+// given Logger, which can be retrieved with logging.FromContext; this
+// way, each request gets its own buffer.
+func NewContext(ctx context.Context, logger *Logger) context.Context {
+	return logging.Ctx(ctx, logger.Scope())
+}
+
+// Scope returns a Logger writing to the same Logger, with the same
+// configuration and level, but with a new, empty buffer.
+func (l *Logger) Scope() *Logger {
+	child := &Logger{config: l.config, scope: &scope{}}
+	child.Wrapper = l.Wrapper.Bind(child, child.derive)
+	return child
+}
+
+// Handle buffers the entry, writes it, or flushes the buffer and then
+// writes it, depending on its level.
+func (l *Logger) Handle(entry *logging.Entry) {
+	l.scope.lock.Lock()
+	if l.scope.triggered {
+		l.scope.lock.Unlock()
+		entry.Replay(l.Unwrap())
+		return
+	}
+	if entry.Level >= l.config.trigger || entry.Level >= logging.LevelPanic {
+		l.scope.lock.Unlock()
+		l.Flush()
+		entry.Replay(l.Unwrap())
+		return
+	}
+	if entry.Level >= l.config.threshold {
+		l.scope.lock.Unlock()
+		entry.Replay(l.Unwrap())
+		return
+	}
+	if l.config.capacity > 0 {
+		if len(l.scope.entries) >= l.config.capacity {
+			copy(l.scope.entries, l.scope.entries[1:])
+			l.scope.entries = l.scope.entries[:len(l.scope.entries)-1]
+		}
+		l.scope.entries = append(l.scope.entries, buffered{logger: l.Unwrap(), entry: entry})
+	}
+	l.scope.lock.Unlock()
+}
+
+// Flush writes the buffered entries of the scope to the wrapped Logger
+// as if an entry at the trigger level had been logged.
+func (l *Logger) Flush() {
+	l.scope.lock.Lock()
+	entries := l.scope.entries
+	l.scope.entries = nil
+	l.scope.triggered = l.config.stay
+	l.scope.lock.Unlock()
+	for _, b := range entries {
+		b.entry.Replay(b.logger)
+	}
+}
+
+// Discard drops the buffered entries of the scope and rearms it, e.g.
+// at the end of a request that completed successfully.
+func (l *Logger) Discard() {
+	l.scope.lock.Lock()
+	defer l.scope.lock.Unlock()
+	l.scope.entries = nil
+	l.scope.triggered = false
+}
@@ -0,0 +1,147 @@
+package fingerscrossed
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dihedron/go-log-facade/logging"
+	"github.com/dihedron/go-log-facade/logging/stream"
+)
+
+var message = regexp.MustCompile(`msg=(\S+)`)
+
+// messages returns the messages written to the buffer, in order, and
+// empties it.
+func messages(buffer *bytes.Buffer) string {
+	var messages []string
+	for _, match := range message.FindAllStringSubmatch(buffer.String(), -1) {
+		messages = append(messages, match[1])
+	}
+	buffer.Reset()
+	return strings.Join(messages, " ")
+}
+
+// newLogger returns a fingers-crossed Logger writing to a logfmt stream
+// Logger at LevelTrace, and the buffer the lines are written to.
+func newLogger(options ...Option) (*Logger, *bytes.Buffer) {
+	buffer := &bytes.Buffer{}
+	inner := stream.NewLogger(buffer, stream.WithEncoder(&stream.LogfmtEncoder{}), stream.WithCaller(logging.CallerOff))
+	inner.SetLevel(logging.LevelTrace)
+	logger := NewLogger(inner, options...)
+	logger.SetLevel(logging.LevelTrace)
+	return logger, buffer
+}
+
+func TestBuffering(t *testing.T) {
+	logger, buffer := newLogger()
+
+	logger.Trace("t1")
+	logger.Debug("d1")
+	logger.Info("i1")
+	if got := messages(buffer); got != "i1" {
+		t.Errorf("got %q before the trigger, want the entries at or above the threshold", got)
+	}
+
+	logger.Named("child").Error("e1")
+	if got := messages(buffer); got != "t1 d1 e1" {
+		t.Errorf("got %q on the trigger, want the buffered entries and then the trigger", got)
+	}
+
+	// the scope is rearmed after the trigger
+	logger.Debug("d2")
+	if got := messages(buffer); got != "" {
+		t.Errorf("got %q after the trigger, want the entries to be buffered again", got)
+	}
+	logger.Warnf("%s", "w2")
+	logger.Error("e2")
+	if got := messages(buffer); got != "w2 d2 e2" {
+		t.Errorf("got %q on the second trigger", got)
+	}
+}
+
+func TestStayTriggered(t *testing.T) {
+	logger, buffer := newLogger(WithStayTriggered(), WithTrigger(logging.LevelWarn))
+
+	logger.Debug("d1")
+	logger.Warn("w1")
+	logger.Debug("d2")
+	logger.Trace("t2")
+	if got := messages(buffer); got != "d1 w1 d2 t2" {
+		t.Errorf("got %q, want all the entries after the trigger", got)
+	}
+
+	logger.Discard()
+	logger.Debug("d3")
+	if got := messages(buffer); got != "" {
+		t.Errorf("got %q after Discard, want the entries to be buffered again", got)
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	logger, buffer := newLogger(WithCapacity(3))
+
+	for _, message := range []string{"1", "2", "3", "4", "5"} {
+		logger.Debug(message)
+	}
+	logger.Flush()
+	if got := messages(buffer); got != "3 4 5" {
+		t.Errorf("got %q, want the newest entries", got)
+	}
+}
+
+func TestDiscard(t *testing.T) {
+	logger, buffer := newLogger()
+
+	logger.Debug("d1")
+	logger.Discard()
+	logger.Debug("d2")
+	logger.Error("e1")
+	if got := messages(buffer); got != "d2 e1" {
+		t.Errorf("got %q, want the entries buffered after Discard only", got)
+	}
+}
+
+func TestScopes(t *testing.T) {
+	logger, buffer := newLogger()
+	first := logging.FromContext(NewContext(context.Background(), logger))
+	second := logging.FromContext(NewContext(context.Background(), logger))
+
+	first.Debug("first")
+	second.Debug("second")
+	logger.Debug("root")
+	second.Error("failed")
+	if got := messages(buffer); got != "second failed" {
+		t.Errorf("got %q, want the entries of the triggered scope only", got)
+	}
+	first.(*Logger).Flush()
+	if got := messages(buffer); got != "first" {
+		t.Errorf("got %q, want the entries of the flushed scope", got)
+	}
+}
+
+// TestTimeOfCall checks that buffered entries report the time of the
+// call, not the time at which they are flushed.
+func TestTimeOfCall(t *testing.T) {
+	logger, buffer := newLogger()
+
+	logger.Debug("buffered")
+	time.Sleep(20 * time.Millisecond)
+	flushed := time.Now()
+	logger.Error("trigger")
+
+	times := regexp.MustCompile(`time=(\S+)`).FindAllStringSubmatch(buffer.String(), -1)
+	if len(times) != 2 {
+		t.Fatalf("got %d entries, want 2:\n%s", len(times), buffer.String())
+	}
+	logged, err := time.Parse(time.RFC3339Nano, times[0][1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !logged.Before(flushed) {
+		t.Errorf("the buffered entry reports %v, after it was flushed (%v)", logged, flushed)
+	}
+}
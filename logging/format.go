@@ -0,0 +1,26 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sprint formats the given arguments the way the text loggers do, i.e.
+// using their default format and separating them with blanks; lazy
+// arguments are evaluated.
+func Sprint(args ...interface{}) string {
+	var buffer strings.Builder
+	for argNum, arg := range Resolve(args) {
+		if argNum > 0 {
+			buffer.WriteString(" ")
+		}
+		buffer.WriteString(fmt.Sprintf("%v", arg))
+	}
+	return buffer.String()
+}
+
+// Sprintf formats the given arguments according to the format specifier;
+// lazy arguments are evaluated.
+func Sprintf(format string, args ...interface{}) string {
+	return fmt.Sprintf(format, Resolve(args)...)
+}
@@ -1,8 +1,8 @@
 package golang
 
 import (
-	"bytes"
 	"fmt"
+	"io"
 	golang "log"
 	"os"
 	"strings"
@@ -10,148 +10,297 @@ import (
 	"github.com/dihedron/go-log-facade/logging"
 )
 
-// Logger is te type wrapping the default Golang logger.
+// Logger is te type wrapping a Golang logger.
 type Logger struct {
 	logger *golang.Logger
-	level  *logging.Level
+	level  *logging.LevelVar
+	name   string
+	fields []interface{}
+	raw    bool
+	caller logging.Caller
+	skip   int
+	pc     uintptr
+	noexit bool
 }
 
-// NewLogger returns a new Golang Logger.
-func NewLogger(prefix string) *Logger {
-	return &Logger{
-		logger: golang.New(os.Stderr, prefix, golang.Ltime|golang.Ldate|golang.Lmicroseconds),
+// Option is a functional option for configuring a Golang Logger.
+type Option func(*Logger)
+
+// WithoutEscaping disables the escaping of line breaks and control
+// characters in names and messages (see logging.Escape), which otherwise
+// prevents untrusted input from forging entries.
+func WithoutEscaping() Option {
+	return func(l *Logger) {
+		l.raw = true
 	}
 }
 
+// WithCaller sets how the location of the logging calls is reported; the
+// default is logging.CallerOff.
+func WithCaller(caller logging.Caller) Option {
+	return func(l *Logger) {
+		l.caller = caller
+	}
+}
+
+// NewLogger returns a new Golang Logger, writing to standard error with
+// the given prefix and a timestamp with microseconds.
+func NewLogger(prefix string, options ...Option) *Logger {
+	return NewLoggerWithWriter(os.Stderr, prefix, golang.Ltime|golang.Ldate|golang.Lmicroseconds, options...)
+}
+
+// NewLoggerWithWriter returns a new Golang Logger, writing to the given
+// writer with the given prefix and flags (see log.New); the Lshortfile
+// and Llongfile flags report the caller of the logging methods, but not
+// that of the entries replayed by wrappers, for which WithCaller works.
+func NewLoggerWithWriter(writer io.Writer, prefix string, flags int, options ...Option) *Logger {
+	return NewLoggerFrom(golang.New(writer, prefix, flags), options...)
+}
+
+// NewLoggerFrom returns a new Golang Logger writing to the given logger,
+// with its prefix, flags and output; if nil, the standard logger of the
+// log package is used.
+func NewLoggerFrom(logger *golang.Logger, options ...Option) *Logger {
+	if logger == nil {
+		logger = golang.Default()
+	}
+	l := &Logger{
+		logger: logger,
+		level:  logging.NewLevelVar(),
+	}
+	for _, option := range options {
+		option(l)
+	}
+	return l
+}
+
 func (l *Logger) SetLevel(level logging.Level) {
-	l.level = &level
+	l.level.Set(level)
 }
 
 func (l *Logger) GetLevel() *logging.Level {
-	if l.level != nil {
-		// there's a specific logging level for this logger
-		return l.level
-	}
-	// there is no per-instance logging level, return the global level
-	level := logging.GetGlobalLevel()
-	return &level
+	// return the per-instance logging level if set, the global level otherwise
+	return l.level.Pointer()
 }
 
 func (l *Logger) ResetLevel() {
-	l.level = nil
+	l.level.Reset()
+}
+
+func (l *Logger) Enabled(level logging.Level) bool {
+	return l.level.Enabled(level)
+}
+
+func (l *Logger) With(keysAndValues ...interface{}) logging.Logger {
+	child := *l
+	child.fields = append(l.fields[:len(l.fields):len(l.fields)], keysAndValues...)
+	return &child
+}
+
+func (l *Logger) Named(name string) logging.Logger {
+	child := *l
+	if l.name != "" {
+		child.name = l.name + "." + name
+	} else {
+		child.name = name
+	}
+	return &child
+}
+
+func (l *Logger) AddCallerSkip(skip int) logging.Logger {
+	child := *l
+	child.skip += skip
+	return &child
+}
+
+func (l *Logger) WithCallerPC(pc uintptr) logging.Logger {
+	child := *l
+	child.pc = pc
+	return &child
+}
+
+func (l *Logger) WithoutExit() logging.Logger {
+	child := *l
+	child.noexit = true
+	return &child
 }
 
 func (l *Logger) Trace(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelTrace {
-		var buffer bytes.Buffer
-		for argNum, arg := range args {
-			if argNum > 0 {
-				buffer.WriteString(" ")
-			}
-			buffer.WriteString(fmt.Sprintf("%v", arg))
-		}
-		message := fmt.Sprintf("[TRC] %s", buffer.String())
-		message = strings.TrimRight(message, "\n\r")
-		golang.Print(message)
+	if l.level.Enabled(logging.LevelTrace) {
+		l.print("TRC", l.format(args...))
 	}
 }
 
 func (l *Logger) Tracef(msg string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelTrace {
-		message := fmt.Sprintf("[TRC] "+msg, args...)
-		message = strings.TrimRight(message, "\n\r")
-		golang.Print(message)
+	if l.level.Enabled(logging.LevelTrace) {
+		l.print("TRC", logging.Sprintf(msg, args...))
+	}
+}
+
+func (l *Logger) Tracew(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelTrace) {
+		l.print("TRC", msg, keysAndValues...)
 	}
 }
 
 func (l *Logger) Debug(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelDebug {
-		var buffer bytes.Buffer
-		for argNum, arg := range args {
-			if argNum > 0 {
-				buffer.WriteString(" ")
-			}
-			buffer.WriteString(fmt.Sprintf("%v", arg))
-		}
-		message := fmt.Sprintf("[DBG] %s", buffer.String())
-		message = strings.TrimRight(message, "\n\r")
-		golang.Print(message)
+	if l.level.Enabled(logging.LevelDebug) {
+		l.print("DBG", l.format(args...))
 	}
 }
 
 func (l *Logger) Debugf(msg string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelDebug {
-		message := fmt.Sprintf("[DBG] "+msg, args...)
-		message = strings.TrimRight(message, "\n\r")
-		golang.Print(message)
+	if l.level.Enabled(logging.LevelDebug) {
+		l.print("DBG", logging.Sprintf(msg, args...))
+	}
+}
+
+func (l *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelDebug) {
+		l.print("DBG", msg, keysAndValues...)
 	}
 }
 
 func (l *Logger) Info(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelInfo {
-		var buffer bytes.Buffer
-		for argNum, arg := range args {
-			if argNum > 0 {
-				buffer.WriteString(" ")
-			}
-			buffer.WriteString(fmt.Sprintf("%v", arg))
-		}
-		message := fmt.Sprintf("[INF] %s", buffer.String())
-		message = strings.TrimRight(message, "\n\r")
-		golang.Print(message)
+	if l.level.Enabled(logging.LevelInfo) {
+		l.print("INF", l.format(args...))
 	}
 }
 
 func (l *Logger) Infof(msg string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelInfo {
-		message := fmt.Sprintf("[INF] "+msg, args...)
-		message = strings.TrimRight(message, "\n\r")
-		golang.Print(message)
+	if l.level.Enabled(logging.LevelInfo) {
+		l.print("INF", logging.Sprintf(msg, args...))
+	}
+}
+
+func (l *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelInfo) {
+		l.print("INF", msg, keysAndValues...)
 	}
 }
 
 func (l *Logger) Warn(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelWarn {
-		var buffer bytes.Buffer
-		for argNum, arg := range args {
-			if argNum > 0 {
-				buffer.WriteString(" ")
-			}
-			buffer.WriteString(fmt.Sprintf("%v", arg))
-		}
-		message := fmt.Sprintf("[WRN] %s", buffer.String())
-		message = strings.TrimRight(message, "\n\r")
-		golang.Print(message)
+	if l.level.Enabled(logging.LevelWarn) {
+		l.print("WRN", l.format(args...))
 	}
 }
 
 func (l *Logger) Warnf(msg string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelWarn {
-		message := fmt.Sprintf("[WRN] "+msg, args...)
-		message = strings.TrimRight(message, "\n\r")
-		golang.Print(message)
+	if l.level.Enabled(logging.LevelWarn) {
+		l.print("WRN", logging.Sprintf(msg, args...))
+	}
+}
+
+func (l *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelWarn) {
+		l.print("WRN", msg, keysAndValues...)
 	}
 }
 
 func (l *Logger) Error(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelError {
-		var buffer bytes.Buffer
-		for argNum, arg := range args {
-			if argNum > 0 {
-				buffer.WriteString(" ")
-			}
-			buffer.WriteString(fmt.Sprintf("%v", arg))
-		}
-		message := fmt.Sprintf("[ERR] %s", buffer.String())
-		message = strings.TrimRight(message, "\n\r")
-		golang.Print(message)
+	if l.level.Enabled(logging.LevelError) {
+		l.print("ERR", l.format(args...))
 	}
 }
 
 func (l *Logger) Errorf(msg string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelError {
-		message := fmt.Sprintf("[ERR] "+msg, args...)
-		message = strings.TrimRight(message, "\n\r")
-		golang.Print(message)
+	if l.level.Enabled(logging.LevelError) {
+		l.print("ERR", logging.Sprintf(msg, args...))
+	}
+}
+
+func (l *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelError) {
+		l.print("ERR", msg, keysAndValues...)
+	}
+}
+
+func (l *Logger) Panic(args ...interface{}) {
+	if l.level.Enabled(logging.LevelPanic) {
+		l.print("PNC", l.format(args...))
+	}
+	panic(l.format(args...))
+}
+
+func (l *Logger) Panicf(msg string, args ...interface{}) {
+	if l.level.Enabled(logging.LevelPanic) {
+		l.print("PNC", logging.Sprintf(msg, args...))
+	}
+	panic(logging.Sprintf(msg, args...))
+}
+
+func (l *Logger) Panicw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelPanic) {
+		l.print("PNC", msg, keysAndValues...)
+	}
+	panic(msg)
+}
+
+func (l *Logger) Fatal(args ...interface{}) {
+	if l.level.Enabled(logging.LevelFatal) {
+		l.print("FTL", l.format(args...))
+	}
+	l.sync()
+	l.exit()
+}
+
+func (l *Logger) Fatalf(msg string, args ...interface{}) {
+	if l.level.Enabled(logging.LevelFatal) {
+		l.print("FTL", logging.Sprintf(msg, args...))
+	}
+	l.sync()
+	l.exit()
+}
+
+func (l *Logger) Fatalw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelFatal) {
+		l.print("FTL", msg, keysAndValues...)
+	}
+	l.sync()
+	l.exit()
+}
+
+// exit terminates the application, unless the Logger was derived with
+// WithoutExit.
+func (l *Logger) exit() {
+	if !l.noexit {
+		logging.Exit(1)
+	}
+}
+
+func (l *Logger) format(args ...interface{}) string {
+	return logging.Sprint(args...)
+}
+
+func (l *Logger) print(level string, message string, keysAndValues ...interface{}) {
+	message = strings.TrimRight(message, "\n\r")
+	if l.name != "" {
+		message = l.name + ": " + message
+	}
+	if !l.raw {
+		message = logging.Escape(message)
+	}
+	keysAndValues, stack := logging.SplitStackTrace(keysAndValues)
+	if fields := logging.FormatFields(append(l.fields[:len(l.fields):len(l.fields)], keysAndValues...)...); fields != "" {
+		message = message + " " + fields
+	}
+	pc := l.pc
+	if pc == 0 && l.caller != logging.CallerOff {
+		// skip print and the logging method
+		pc = logging.CallerPC(2 + l.skip)
+	}
+	if location := logging.FormatFrame(l.caller.Frame(pc)); location != "" {
+		message = message + " (" + location + ")"
+	}
+	if stack != nil {
+		message = message + "\n\t" + strings.ReplaceAll(stack.String(), "\n", "\n\t")
+	}
+	// skip Output, print and the logging method for Lshortfile and Llongfile
+	_ = l.logger.Output(3+l.skip, fmt.Sprintf("[%s] %s", level, message))
+}
+
+func (l *Logger) sync() {
+	if syncer, ok := l.logger.Writer().(interface{ Sync() error }); ok {
+		_ = syncer.Sync()
 	}
 }
@@ -1,8 +1,6 @@
 package hcl
 
 import (
-	"bytes"
-	"fmt"
 	"strings"
 
 	"github.com/dihedron/go-log-facade/logging"
@@ -12,127 +10,381 @@ import (
 // Logger is the tpe warring an HCL logger.
 type Logger struct {
 	logger hclog.Logger
-	level  *logging.Level
+	level  *logging.LevelVar
+	raw    bool
+	stack  logging.Level
+	caller logging.Caller
+	skip   int
+	pc     uintptr
+	trace  logging.StackTrace
+	noexit bool
+}
+
+// Option is a functional option for configuring an HCL Logger.
+type Option func(*Logger)
+
+// WithoutEscaping disables the escaping of line breaks and control
+// characters in messages, names and string values (see logging.Escape),
+// which otherwise prevents untrusted input from forging entries; use it
+// when the hclog Logger writes JSON, which is escaped anyway.
+func WithoutEscaping() Option {
+	return func(l *Logger) {
+		l.raw = true
+	}
+}
+
+// WithStackTrace adds the stack trace of the call, as a "stack" field, to
+// entries at or above the given level; by default, stack traces are only
+// added on demand, with logging.Stack.
+func WithStackTrace(level logging.Level) Option {
+	return func(l *Logger) {
+		l.stack = level
+	}
+}
+
+// WithCaller adds the location of the logging calls, as a "caller" field,
+// as configured; the default is logging.CallerOff. Note that hclog's own
+// IncludeLocation option reports this package instead.
+func WithCaller(caller logging.Caller) Option {
+	return func(l *Logger) {
+		l.caller = caller
+	}
 }
 
 // NewLogger returns an instance of HCL logger wrapper
 // that complies with the logging.Logger interface.
-func NewLogger(logger hclog.Logger) *Logger {
-	return &Logger{
+func NewLogger(logger hclog.Logger, options ...Option) *Logger {
+	l := &Logger{
 		logger: logger,
+		level:  logging.NewLevelVar(),
+		stack:  logging.LevelOff,
+	}
+	for _, option := range options {
+		option(l)
 	}
+	return l
 }
 
 func (l *Logger) SetLevel(level logging.Level) {
-	l.level = &level
+	l.level.Set(level)
 }
 
 func (l *Logger) GetLevel() *logging.Level {
-	if l.level != nil {
-		// there's a specific logging level for this logger
-		return l.level
-	}
-	// there is no per-instance logging level, return the global level
-	level := logging.GetGlobalLevel()
-	return &level
+	// return the per-instance logging level if set, the global level otherwise
+	return l.level.Pointer()
 }
 
 func (l *Logger) ResetLevel() {
-	l.level = nil
+	l.level.Reset()
+}
+
+// Enabled returns whether messages at the given level would be written,
+// according to both this Logger's level and the hclog Logger's; panic and
+// fatal messages are written as hclog errors.
+func (l *Logger) Enabled(level logging.Level) bool {
+	if !l.level.Enabled(level) {
+		return false
+	}
+	switch level {
+	case logging.LevelTrace:
+		return l.logger.IsTrace()
+	case logging.LevelDebug:
+		return l.logger.IsDebug()
+	case logging.LevelInfo:
+		return l.logger.IsInfo()
+	case logging.LevelWarn:
+		return l.logger.IsWarn()
+	case logging.LevelError, logging.LevelPanic, logging.LevelFatal:
+		return l.logger.IsError()
+	}
+	return false
+}
+
+// With returns a child Logger that adds the given key/value pairs to
+// every message, using hclog's native implied arguments; the child
+// shares the logging level of its parent.
+func (l *Logger) With(keysAndValues ...interface{}) logging.Logger {
+	child := *l
+	child.logger = l.logger.With(l.values(keysAndValues)...)
+	return &child
+}
+
+// Named returns a child Logger whose name is the given name appended
+// to the parent's name, using hclog's native naming.
+func (l *Logger) Named(name string) logging.Logger {
+	child := *l
+	if !l.raw {
+		name = logging.Escape(name)
+	}
+	child.logger = l.logger.Named(name)
+	return &child
+}
+
+// AddCallerSkip returns a child Logger that reports as caller the function
+// skip frames further up the stack; it shares the logging level of its
+// parent.
+func (l *Logger) AddCallerSkip(skip int) logging.Logger {
+	child := *l
+	child.skip += skip
+	return &child
+}
+
+// WithCallerPC returns a child Logger that reports the given program
+// counter as the caller of all its logging calls; it shares the logging
+// level of its parent.
+func (l *Logger) WithCallerPC(pc uintptr) logging.Logger {
+	child := *l
+	child.pc = pc
+	return &child
+}
+
+// WithoutExit returns a child Logger whose Fatal methods log the message
+// and return instead of exiting; it shares the logging level of its
+// parent.
+func (l *Logger) WithoutExit() logging.Logger {
+	child := *l
+	child.noexit = true
+	return &child
+}
+
+// StackLevel returns the level at or above which entries get the stack
+// trace of the call.
+func (l *Logger) StackLevel() logging.Level {
+	return l.stack
+}
+
+// WithStack returns a child Logger that reports the given stack trace,
+// instead of capturing its own, for the entries at or above its stack
+// level; it shares the logging level of its parent.
+func (l *Logger) WithStack(stack logging.StackTrace) logging.Logger {
+	child := *l
+	child.trace = stack
+	return &child
 }
 
 // Trace logs a message at LevelTrace level.
 func (l *Logger) Trace(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelTrace {
+	if l.level.Enabled(logging.LevelTrace) {
 		message := l.format(args...)
-		l.logger.Trace(message)
+		l.logger.Trace(message, l.args(logging.LevelTrace, nil)...)
 	}
 }
 
 // Tracef logs a message at LevelTrace level.
 func (l *Logger) Tracef(msg string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelTrace {
+	if l.level.Enabled(logging.LevelTrace) {
 		message := l.formatf(msg, args...)
-		l.logger.Trace(message)
+		l.logger.Trace(message, l.args(logging.LevelTrace, nil)...)
+	}
+}
+
+// Tracew logs a message with the given key/value pairs at LevelTrace level.
+func (l *Logger) Tracew(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelTrace) {
+		l.logger.Trace(l.formatw(msg), l.args(logging.LevelTrace, keysAndValues)...)
 	}
 }
 
 // Debug logs a message at LevelDebug level.
 func (l *Logger) Debug(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelDebug {
+	if l.level.Enabled(logging.LevelDebug) {
 		message := l.format(args...)
-		l.logger.Debug(message)
+		l.logger.Debug(message, l.args(logging.LevelDebug, nil)...)
 	}
 }
 
 // Debugf logs a message at LevelDebug level.
 func (l *Logger) Debugf(msg string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelDebug {
+	if l.level.Enabled(logging.LevelDebug) {
 		message := l.formatf(msg, args...)
-		l.logger.Debug(message)
+		l.logger.Debug(message, l.args(logging.LevelDebug, nil)...)
+	}
+}
+
+// Debugw logs a message with the given key/value pairs at LevelDebug level.
+func (l *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelDebug) {
+		l.logger.Debug(l.formatw(msg), l.args(logging.LevelDebug, keysAndValues)...)
 	}
 }
 
 // Info logs a message at LevelInfo level.
 func (l *Logger) Info(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelInfo {
+	if l.level.Enabled(logging.LevelInfo) {
 		message := l.format(args...)
-		l.logger.Info(message)
+		l.logger.Info(message, l.args(logging.LevelInfo, nil)...)
 	}
 }
 
 // Infof logs a message at LevelInfo level.
 func (l *Logger) Infof(msg string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelInfo {
+	if l.level.Enabled(logging.LevelInfo) {
 		message := l.formatf(msg, args...)
-		l.logger.Info(message)
+		l.logger.Info(message, l.args(logging.LevelInfo, nil)...)
+	}
+}
+
+// Infow logs a message with the given key/value pairs at LevelInfo level.
+func (l *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelInfo) {
+		l.logger.Info(l.formatw(msg), l.args(logging.LevelInfo, keysAndValues)...)
 	}
 }
 
 // Warn logs a message at LevelWarn level.
 func (l *Logger) Warn(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelWarn {
+	if l.level.Enabled(logging.LevelWarn) {
 		message := l.format(args...)
-		l.logger.Warn(message)
+		l.logger.Warn(message, l.args(logging.LevelWarn, nil)...)
 	}
 }
 
 // Warnf logs a message at LevelWarn level.
 func (l *Logger) Warnf(msg string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelWarn {
+	if l.level.Enabled(logging.LevelWarn) {
 		message := l.formatf(msg, args...)
-		l.logger.Warn(message)
+		l.logger.Warn(message, l.args(logging.LevelWarn, nil)...)
+	}
+}
+
+// Warnw logs a message with the given key/value pairs at LevelWarn level.
+func (l *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelWarn) {
+		l.logger.Warn(l.formatw(msg), l.args(logging.LevelWarn, keysAndValues)...)
 	}
 }
 
 // Error logs a message at LevelError level.
 func (l *Logger) Error(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelError {
+	if l.level.Enabled(logging.LevelError) {
 		message := l.format(args...)
-		l.logger.Error(message)
+		l.logger.Error(message, l.args(logging.LevelError, nil)...)
 	}
 }
 
 // Errorf logs a message at LevelError level.
 func (l *Logger) Errorf(msg string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelError {
+	if l.level.Enabled(logging.LevelError) {
 		message := l.formatf(msg, args...)
-		l.logger.Error(message)
+		l.logger.Error(message, l.args(logging.LevelError, nil)...)
 	}
 }
 
-func (l *Logger) format(args ...interface{}) string {
-	var buffer bytes.Buffer
-	for argNum, arg := range args {
-		if argNum > 0 {
-			buffer.WriteString(" ")
-		}
-		buffer.WriteString(fmt.Sprintf("%v", arg))
+// Errorw logs a message with the given key/value pairs at LevelError level.
+func (l *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelError) {
+		l.logger.Error(l.formatw(msg), l.args(logging.LevelError, keysAndValues)...)
+	}
+}
+
+// Panic logs a message at LevelPanic level (as an hclog error), then panics.
+func (l *Logger) Panic(args ...interface{}) {
+	if l.level.Enabled(logging.LevelPanic) {
+		message := l.format(args...)
+		l.logger.Error(message, l.args(logging.LevelPanic, nil)...)
+	}
+	panic(l.format(args...))
+}
+
+// Panicf logs a message at LevelPanic level (as an hclog error), then panics.
+func (l *Logger) Panicf(msg string, args ...interface{}) {
+	if l.level.Enabled(logging.LevelPanic) {
+		message := l.formatf(msg, args...)
+		l.logger.Error(message, l.args(logging.LevelPanic, nil)...)
+	}
+	panic(l.formatf(msg, args...))
+}
+
+// Panicw logs a message with the given key/value pairs at LevelPanic level
+// (as an hclog error), then panics.
+func (l *Logger) Panicw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelPanic) {
+		l.logger.Error(l.formatw(msg), l.args(logging.LevelPanic, keysAndValues)...)
+	}
+	panic(msg)
+}
+
+// Fatal logs a message at LevelFatal level (as an hclog error), then exits.
+func (l *Logger) Fatal(args ...interface{}) {
+	if l.level.Enabled(logging.LevelFatal) {
+		message := l.format(args...)
+		l.logger.Error(message, l.args(logging.LevelFatal, nil)...)
 	}
-	return strings.TrimRight(buffer.String(), "\n\r")
+	l.exit()
+}
+
+// Fatalf logs a message at LevelFatal level (as an hclog error), then exits.
+func (l *Logger) Fatalf(msg string, args ...interface{}) {
+	if l.level.Enabled(logging.LevelFatal) {
+		message := l.formatf(msg, args...)
+		l.logger.Error(message, l.args(logging.LevelFatal, nil)...)
+	}
+	l.exit()
+}
+
+// Fatalw logs a message with the given key/value pairs at LevelFatal level
+// (as an hclog error), then exits.
+func (l *Logger) Fatalw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelFatal) {
+		l.logger.Error(l.formatw(msg), l.args(logging.LevelFatal, keysAndValues)...)
+	}
+	l.exit()
+}
+
+// exit terminates the application, unless the Logger was derived with
+// WithoutExit.
+func (l *Logger) exit() {
+	if !l.noexit {
+		logging.Exit(1)
+	}
+}
+
+func (l *Logger) format(args ...interface{}) string {
+	return l.formatw(logging.Sprint(args...))
 }
 
 func (l *Logger) formatf(msg string, args ...interface{}) string {
-	message := fmt.Sprintf(msg, args...)
-	return strings.TrimRight(message, "\n\r")
+	return l.formatw(logging.Sprintf(msg, args...))
+}
+
+func (l *Logger) formatw(msg string) string {
+	message := strings.TrimRight(msg, "\n\r")
+	if !l.raw {
+		message = logging.Escape(message)
+	}
+	return message
+}
+
+func (l *Logger) values(keysAndValues []interface{}) []interface{} {
+	if l.raw {
+		return logging.Resolve(keysAndValues)
+	}
+	return logging.EscapeFields(keysAndValues...)
+}
+
+// args returns the key/value pairs for an entry at the given level, with
+// the location of the call added if configured, and its stack trace if
+// the level requires it or if it is requested among them; it must be
+// called directly by the logging methods.
+func (l *Logger) args(level logging.Level, keysAndValues []interface{}) []interface{} {
+	keysAndValues, stack := logging.SplitStackTrace(keysAndValues)
+	if stack == nil && level >= l.stack {
+		stack = l.trace
+		if stack == nil {
+			stack = logging.CaptureStackTrace()
+		}
+	}
+	keysAndValues = l.values(keysAndValues)
+	pc := l.pc
+	if pc == 0 && l.caller != logging.CallerOff {
+		// skip args and the logging method
+		pc = logging.CallerPC(2 + l.skip)
+	}
+	if location := logging.FormatFrame(l.caller.Frame(pc)); location != "" {
+		keysAndValues = append(keysAndValues[:len(keysAndValues):len(keysAndValues)], logging.CallerKey, location)
+	}
+	if stack != nil {
+		keysAndValues = append(keysAndValues[:len(keysAndValues):len(keysAndValues)], logging.StackKey, stack.String())
+	}
+	return keysAndValues
 }
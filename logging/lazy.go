@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"fmt"
+)
+
+// Lazy wraps a function computing an expensive log argument, so that
+// the value is only evaluated if and when the message is actually
+// written, i.e. after the logging level has been checked:
+//
+//	logger.Debugf("payload: %s", logging.Lazy(func() interface{} {
+//		return logging.ToPrettyJSON(payload)
+//	}))
+//
+// Plain func() interface{} arguments are treated the same way.
+type Lazy func() interface{}
+
+// String evaluates the function and formats its result.
+func (f Lazy) String() string {
+	return fmt.Sprintf("%v", f())
+}
+
+// Resolve returns the given arguments with all Lazy and func() interface{}
+// values replaced by their results; if there is nothing to evaluate, the
+// original slice is returned as is.
+func Resolve(args []interface{}) []interface{} {
+	lazy := false
+	for _, arg := range args {
+		if isLazy(arg) {
+			lazy = true
+			break
+		}
+	}
+	if !lazy {
+		return args
+	}
+	resolved := make([]interface{}, len(args))
+	for i, arg := range args {
+		resolved[i] = resolve(arg)
+	}
+	return resolved
+}
+
+func isLazy(arg interface{}) bool {
+	switch arg.(type) {
+	case Lazy, func() interface{}:
+		return true
+	}
+	return false
+}
+
+func resolve(arg interface{}) interface{} {
+	switch f := arg.(type) {
+	case Lazy:
+		return f()
+	case func() interface{}:
+		return f()
+	}
+	return arg
+}
@@ -0,0 +1,187 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+var levelNames = [...]string{
+	LevelTrace: "trace",
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+	LevelPanic: "panic",
+	LevelFatal: "fatal",
+	LevelOff:   "off",
+}
+
+// levelAliases maps all the accepted (lowercase) level names, including
+// the three-letter tags used by the text loggers, to their Level.
+var levelAliases = map[string]Level{
+	"trace":       LevelTrace,
+	"trc":         LevelTrace,
+	"debug":       LevelDebug,
+	"dbg":         LevelDebug,
+	"info":        LevelInfo,
+	"inf":         LevelInfo,
+	"information": LevelInfo,
+	"warn":        LevelWarn,
+	"wrn":         LevelWarn,
+	"warning":     LevelWarn,
+	"error":       LevelError,
+	"err":         LevelError,
+	"panic":       LevelPanic,
+	"pnc":         LevelPanic,
+	"fatal":       LevelFatal,
+	"ftl":         LevelFatal,
+	"off":         LevelOff,
+	"none":        LevelOff,
+	"disabled":    LevelOff,
+}
+
+// ParseLevel returns the Level corresponding to the given name; the
+// comparison is case-insensitive and accepts both canonical names (e.g.
+// "warn") and aliases (e.g. "warning" or "WRN"), as well as numeric
+// values (e.g. "7" for LevelOff).
+func ParseLevel(name string) (Level, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if level, ok := levelAliases[key]; ok {
+		return level, nil
+	}
+	if number, err := strconv.ParseUint(key, 10, 8); err == nil && Level(number).IsValid() {
+		return Level(number), nil
+	}
+	return LevelOff, fmt.Errorf("invalid logging level: %q", name)
+}
+
+// String returns the canonical name of the Level.
+func (l Level) String() string {
+	if l.IsValid() {
+		return levelNames[l]
+	}
+	return fmt.Sprintf("Level(%d)", l)
+}
+
+// IsValid returns whether the Level is one of the predefined values.
+func (l Level) IsValid() bool {
+	return l <= LevelOff
+}
+
+// MarshalText implements encoding.TextMarshaler, which is used by both
+// encoding/json and gopkg.in/yaml.v3.
+func (l Level) MarshalText() ([]byte, error) {
+	if !l.IsValid() {
+		return nil, fmt.Errorf("invalid logging level: %d", l)
+	}
+	return []byte(levelNames[l]), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, which is used by
+// both encoding/json and gopkg.in/yaml.v3.
+func (l *Level) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, so that levels can be given
+// as numbers as well as names.
+func (l *Level) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		// not a string, e.g. a number
+		name = string(data)
+	}
+	return l.UnmarshalText([]byte(name))
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, so that levels can be given
+// as numbers as well as names.
+func (l *Level) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.ScalarNode {
+		return fmt.Errorf("invalid logging level at line %d: not a scalar", value.Line)
+	}
+	return l.UnmarshalText([]byte(value.Value))
+}
+
+// Set implements flag.Value, so that a Level can be used with flag.Var.
+func (l *Level) Set(name string) error {
+	return l.UnmarshalText([]byte(name))
+}
+
+// LevelVar is a logging level that can be safely read and changed at
+// runtime while other goroutines are logging; loggers and their children
+// share a LevelVar, and an unset LevelVar defers to the global logging
+// level. A nil *LevelVar behaves like an unset one.
+type LevelVar struct {
+	// value holds the level plus one, so that zero means unset.
+	value atomic.Uint32
+}
+
+// NewLevelVar returns a new, unset LevelVar.
+func NewLevelVar() *LevelVar {
+	return &LevelVar{}
+}
+
+// Set sets the level, overriding the global logging level.
+func (v *LevelVar) Set(level Level) {
+	v.value.Store(uint32(level) + 1)
+}
+
+// Reset removes the level, so that the global logging level applies.
+func (v *LevelVar) Reset() {
+	v.value.Store(0)
+}
+
+// Get returns the level and whether it is set.
+func (v *LevelVar) Get() (Level, bool) {
+	if v == nil {
+		return LevelOff, false
+	}
+	if value := v.value.Load(); value != 0 {
+		return Level(value - 1), true
+	}
+	return LevelOff, false
+}
+
+// Level returns the effective level, i.e. the level if set or the
+// global logging level otherwise.
+func (v *LevelVar) Level() Level {
+	if level, ok := v.Get(); ok {
+		return level
+	}
+	return Level(global.Load())
+}
+
+// levelValues holds one value per Level, so that Pointer can return
+// pointers to them without allocating.
+var levelValues = [...]Level{LevelTrace, LevelDebug, LevelInfo, LevelWarn, LevelError, LevelPanic, LevelFatal, LevelOff}
+
+// Pointer returns a pointer to the effective level, as returned by Level,
+// without allocating, so that it can be used to implement GetLevel; the
+// pointed value is shared and must not be modified.
+func (v *LevelVar) Pointer() *Level {
+	if level := v.Level(); level.IsValid() {
+		return &levelValues[level]
+	}
+	invalid := v.Level()
+	return &invalid
+}
+
+// Enabled returns whether messages at the given level pass the effective
+// level; it neither locks nor allocates.
+func (v *LevelVar) Enabled(level Level) bool {
+	return v.Level() <= level
+}
@@ -0,0 +1,197 @@
+package logging_test
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dihedron/go-log-facade/logging"
+	"github.com/dihedron/go-log-facade/logging/stream"
+	"github.com/dihedron/go-log-facade/logging/tee"
+	"gopkg.in/yaml.v3"
+)
+
+// TestLevelConcurrency changes the levels of parent and child loggers and
+// the global level while logging through them; run it with -race.
+func TestLevelConcurrency(t *testing.T) {
+	defer logging.SetGlobalLevel(logging.GetGlobalLevel())
+
+	parent := stream.NewLogger(io.Discard)
+	loggers := []logging.Logger{
+		parent,
+		parent.With("key", "value"),
+		parent.Named("child"),
+		parent.Named("child").With("key", "value"),
+	}
+	loggers = append(loggers, tee.NewLogger(tee.Sink{Logger: loggers[1], Level: logging.LevelTrace}))
+
+	var wg sync.WaitGroup
+	for _, logger := range loggers {
+		logger := logger
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				logger.Debugw("message", "i", i)
+				logger.Infof("message %d", i)
+				if logger.Enabled(logging.LevelTrace) {
+					logger.Trace("message")
+				}
+				_ = *logger.GetLevel()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				logger.SetLevel(logging.Level(i % int(logging.LevelPanic)))
+				logger.ResetLevel()
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			logging.SetGlobalLevel(logging.Level(i % int(logging.LevelPanic)))
+		}
+	}()
+	wg.Wait()
+}
+
+func TestLevelSharedWithChildren(t *testing.T) {
+	defer logging.SetGlobalLevel(logging.GetGlobalLevel())
+	logging.SetGlobalLevel(logging.LevelInfo)
+
+	parent := stream.NewLogger(io.Discard)
+	child := parent.With("key", "value").Named("child")
+	if level := *child.GetLevel(); level != logging.LevelInfo {
+		t.Fatalf("child level = %v, want the global level %v", level, logging.LevelInfo)
+	}
+	parent.SetLevel(logging.LevelError)
+	if level := *child.GetLevel(); level != logging.LevelError {
+		t.Fatalf("child level = %v after parent.SetLevel, want %v", level, logging.LevelError)
+	}
+	child.ResetLevel()
+	if level := *parent.GetLevel(); level != logging.LevelInfo {
+		t.Fatalf("parent level = %v after child.ResetLevel, want %v", level, logging.LevelInfo)
+	}
+}
+
+func TestGetLevelDoesNotAllocate(t *testing.T) {
+	logger := stream.NewLogger(io.Discard)
+	logger.SetLevel(logging.LevelWarn)
+	if allocs := testing.AllocsPerRun(100, func() { _ = logger.GetLevel() }); allocs != 0 {
+		t.Fatalf("GetLevel allocates %v times per call", allocs)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]logging.Level{
+		"trace": logging.LevelTrace, "TRC": logging.LevelTrace,
+		"debug": logging.LevelDebug, "Dbg": logging.LevelDebug,
+		"info": logging.LevelInfo, "INFORMATION": logging.LevelInfo,
+		"warn": logging.LevelWarn, "WRN": logging.LevelWarn, "warning": logging.LevelWarn,
+		" error ": logging.LevelError, "err": logging.LevelError,
+		"panic": logging.LevelPanic, "fatal": logging.LevelFatal,
+		"off": logging.LevelOff, "none": logging.LevelOff, "Disabled": logging.LevelOff,
+		"0": logging.LevelTrace, "3": logging.LevelWarn, "7": logging.LevelOff,
+	}
+	for name, want := range tests {
+		if level, err := logging.ParseLevel(name); err != nil || level != want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v", name, level, err, want)
+		}
+	}
+	for _, name := range []string{"", "verbose", "8", "-1", "256"} {
+		if level, err := logging.ParseLevel(name); err == nil {
+			t.Errorf("ParseLevel(%q) = %v, want an error", name, level)
+		} else if !strings.Contains(err.Error(), fmt.Sprintf("%q", name)) {
+			t.Errorf("ParseLevel(%q) error %q does not report the name", name, err)
+		}
+	}
+}
+
+func TestLevelString(t *testing.T) {
+	if s := logging.LevelWarn.String(); s != "warn" {
+		t.Errorf("LevelWarn.String() = %q", s)
+	}
+	if s := logging.Level(42).String(); s != "Level(42)" {
+		t.Errorf("Level(42).String() = %q", s)
+	}
+	if _, err := logging.Level(42).MarshalText(); err == nil {
+		t.Errorf("invalid level marshalled without error")
+	}
+}
+
+type levelConfig struct {
+	Level logging.Level `json:"level" yaml:"level"`
+}
+
+func TestLevelJSON(t *testing.T) {
+	data, err := json.Marshal(levelConfig{Level: logging.LevelWarn})
+	if err != nil || string(data) != `{"level":"warn"}` {
+		t.Fatalf("json.Marshal = %s, %v", data, err)
+	}
+	for input, want := range map[string]logging.Level{
+		`{"level":"warn"}`:  logging.LevelWarn,
+		`{"level":"ERROR"}`: logging.LevelError,
+		`{"level":2}`:       logging.LevelInfo,
+		`{"level":"7"}`:     logging.LevelOff,
+		`{"level":null}`:    logging.LevelDebug,
+	} {
+		config := levelConfig{Level: logging.LevelDebug}
+		if err := json.Unmarshal([]byte(input), &config); err != nil || config.Level != want {
+			t.Errorf("json.Unmarshal(%s) = %v, %v; want %v", input, config.Level, err, want)
+		}
+	}
+	for _, input := range []string{`{"level":"verbose"}`, `{"level":9}`, `{"level":[]}`} {
+		var config levelConfig
+		if err := json.Unmarshal([]byte(input), &config); err == nil {
+			t.Errorf("json.Unmarshal(%s) = %v, want an error", input, config.Level)
+		}
+	}
+}
+
+func TestLevelYAML(t *testing.T) {
+	data, err := yaml.Marshal(levelConfig{Level: logging.LevelFatal})
+	if err != nil || string(data) != "level: fatal\n" {
+		t.Fatalf("yaml.Marshal = %q, %v", data, err)
+	}
+	for input, want := range map[string]logging.Level{
+		"level: fatal":   logging.LevelFatal,
+		"level: WRN":     logging.LevelWarn,
+		"level: 3":       logging.LevelWarn,
+		`level: "off"`:   logging.LevelOff,
+		"level: warning": logging.LevelWarn,
+	} {
+		var config levelConfig
+		if err := yaml.Unmarshal([]byte(input), &config); err != nil || config.Level != want {
+			t.Errorf("yaml.Unmarshal(%q) = %v, %v; want %v", input, config.Level, err, want)
+		}
+	}
+	for _, input := range []string{"level: verbose", "level: [info]"} {
+		var config levelConfig
+		if err := yaml.Unmarshal([]byte(input), &config); err == nil {
+			t.Errorf("yaml.Unmarshal(%q) = %v, want an error", input, config.Level)
+		}
+	}
+}
+
+func TestLevelFlag(t *testing.T) {
+	level := logging.LevelInfo
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.Var(&level, "level", "logging level")
+	if err := flags.Parse([]string{"-level", "WARNING"}); err != nil || level != logging.LevelWarn {
+		t.Errorf("-level WARNING = %v, %v", level, err)
+	}
+	if err := flags.Parse([]string{"-level", "verbose"}); err == nil {
+		t.Errorf("-level verbose accepted")
+	}
+	if s := flags.Lookup("level").Value.String(); s != "warn" {
+		t.Errorf("flag value = %q, want %q", s, "warn")
+	}
+}
@@ -0,0 +1,186 @@
+package limit
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/dihedron/go-log-facade/logging"
+)
+
+type config struct {
+	entry int
+	arg   int
+}
+
+// Option is a functional option for configuring a size-limiting Logger.
+type Option func(*config)
+
+// WithMaxEntrySize sets the maximum size in bytes of the text of an entry,
+// i.e. its message and the values of its key/value pairs; the default is
+// 64KB, zero disables the limit.
+func WithMaxEntrySize(size int) Option {
+	return func(c *config) {
+		c.entry = size
+	}
+}
+
+// WithMaxArgSize sets the maximum size in bytes of the text of a single
+// argument or value; the default is 16KB, zero disables the limit.
+func WithMaxArgSize(size int) Option {
+	return func(c *config) {
+		c.arg = size
+	}
+}
+
+// Logger is a logger that caps the size of the entries passed to the
+// wrapped Logger, whatever its backend: arguments and values longer than
+// the per-argument limit are cut, and so are the message and the values,
+// in this order, once the per-entry limit is reached; keys and the text
+// of Printf-style formats are never cut. Cut text is followed by a single
+// marker reporting how much of the original text has been removed, like
+// "…[truncated 1.2MB]", which is not counted in the limits, and never
+// ends in the middle of a UTF-8 sequence. Arguments that are not strings
+// keep their type unless they are cut.
+type Logger struct {
+	logging.Wrapper
+	config *config
+}
+
+// NewLogger returns a size-limiting Logger wrapping the given Logger.
+func NewLogger(logger logging.Logger, options ...Option) *Logger {
+	c := &config{
+		entry: 64 * 1024,
+		arg:   16 * 1024,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	l := &Logger{config: c}
+	l.Wrapper = logging.NewWrapper(logger, l, l.derive)
+	return l
+}
+
+// derive returns a child Logger with the same configuration.
+func (l *Logger) derive(w logging.Wrapper) logging.Logger {
+	child := &Logger{config: l.config}
+	child.Wrapper = w.Bind(child, child.derive)
+	return child
+}
+
+// With returns a child Logger that adds the given key/value pairs, cut to
+// the per-argument limit, to every message; it shares the logging level
+// of its parent.
+func (l *Logger) With(keysAndValues ...interface{}) logging.Logger {
+	limited := make([]interface{}, len(keysAndValues))
+	for i, value := range keysAndValues {
+		value, text := textOf(value)
+		limited[i] = cut(value, text, l.keep(len(text), nil))
+	}
+	return l.Derive(l.Unwrap().With(limited...))
+}
+
+// Handle cuts the entry to the configured limits and writes it to the
+// wrapped Logger.
+func (l *Logger) Handle(entry *logging.Entry) {
+	limited := &logging.Entry{
+		Time:   entry.Time,
+		Level:  entry.Level,
+		Style:  entry.Style,
+		Format: entry.Format,
+		Args:   make([]interface{}, len(entry.Args)),
+		PC:     entry.PC,
+		Stack:  entry.Stack,
+	}
+	budget := l.config.entry
+	if entry.Style == logging.StylePrintw {
+		if l.config.entry > 0 {
+			limited.Format = logging.Truncate(entry.Format, budget)
+			budget -= min(len(entry.Format), budget)
+		}
+		for i := 0; i < len(entry.Args); i++ {
+			if _, ok := entry.Args[i].(string); ok && i < len(entry.Args)-1 {
+				// keep the key, and limit its value
+				limited.Args[i] = entry.Args[i]
+				i++
+			}
+			value, text := textOf(entry.Args[i])
+			limited.Args[i] = cut(value, text, l.keep(len(text), &budget))
+		}
+		limited.Replay(l.Unwrap())
+		return
+	}
+	values := make([]interface{}, len(entry.Args))
+	texts := make([]string, len(entry.Args))
+	length := 0
+	for i, arg := range entry.Args {
+		values[i], texts[i] = textOf(arg)
+		limited.Args[i] = cut(values[i], texts[i], l.keep(len(texts[i]), nil))
+		if text, ok := limited.Args[i].(string); ok && len(text) != len(texts[i]) {
+			length += len(text)
+		} else {
+			length += len(texts[i])
+		}
+	}
+	if l.config.entry > 0 {
+		if message := limited.Message(); len(message) > l.config.entry {
+			// spend what the rest of the message leaves of the budget on
+			// the arguments, in order, cutting them from their original
+			// text so that each one has a single marker
+			budget -= len(message) - length
+			for i := range limited.Args {
+				limited.Args[i] = cut(values[i], texts[i], l.keep(len(texts[i]), &budget))
+			}
+		}
+	}
+	limited.Replay(l.Unwrap())
+}
+
+// keep returns how many bytes of a text of the given length can be kept
+// within the per-argument limit and, if budget is not nil, within the
+// remaining entry budget, which is decreased accordingly.
+func (l *Logger) keep(length int, budget *int) int {
+	keep := length
+	if l.config.arg > 0 && keep > l.config.arg {
+		keep = l.config.arg
+	}
+	if l.config.entry > 0 && budget != nil {
+		keep = min(keep, max(*budget, 0))
+		*budget -= keep
+	}
+	return keep
+}
+
+// cut returns the value, or its text cut to keep bytes and followed by a
+// marker reporting how much of it has been removed, if it is longer.
+func cut(value interface{}, text string, keep int) interface{} {
+	if len(text) > keep {
+		return logging.Truncate(text, keep)
+	}
+	return value
+}
+
+// textOf returns the value, resolved if lazy, and its text; the text of
+// numbers, booleans and other scalars, which are always short, and of
+// stack traces, which are never cut, is not computed and returned as
+// empty.
+func textOf(value interface{}) (interface{}, string) {
+	value = logging.Resolve([]interface{}{value})[0]
+	switch v := value.(type) {
+	case logging.StackTrace:
+		// stack traces are written by the backends on their own
+		return v, ""
+	case string:
+		return v, v
+	case []byte:
+		return v, string(v)
+	case error:
+		return v, v.Error()
+	case fmt.Stringer:
+		return v, v.String()
+	}
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct, reflect.Pointer:
+		return value, fmt.Sprintf("%v", value)
+	}
+	return value, ""
+}
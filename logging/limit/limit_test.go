@@ -0,0 +1,71 @@
+package limit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dihedron/go-log-facade/logging"
+	"github.com/dihedron/go-log-facade/logging/stream"
+)
+
+func TestLimits(t *testing.T) {
+	long := strings.Repeat("a", 100)
+	tests := []struct {
+		name      string
+		arg, size int
+		log       func(logging.Logger)
+		want      string
+	}{
+		{
+			"values", 5, 20,
+			func(l logging.Logger) { l.Infow("message", "k", "0123456789", "k2", "0123456789") },
+			`msg=message k="01234…[truncated 5B]" k2="01234…[truncated 5B]"`,
+		},
+		{
+			"values over the entry limit", 5, 15,
+			func(l logging.Logger) { l.Infow("message", "k", "0123456789", "k2", "0123456789") },
+			`msg=message k="01234…[truncated 5B]" k2="012…[truncated 7B]"`,
+		},
+		{
+			"message over the entry limit", 0, 10,
+			func(l logging.Logger) { l.Infow(strings.Repeat("m", 30), "k", "v") },
+			`msg="mmmmmmmmmm…[truncated 20B]" k="…[truncated 1B]"`,
+		},
+		{
+			"printf", 5, 10,
+			func(l logging.Logger) { l.Infof("%s %d", "abcdefghij", 42) },
+			`msg="abcde…[truncated 5B] 42"`,
+		},
+		{
+			"printf over the entry limit", 0, 10,
+			func(l logging.Logger) { l.Infof("x=%s", long) },
+			`msg="x=aaaaaaaa…[truncated 92B]"`,
+		},
+		{
+			"print", 5, 14,
+			func(l logging.Logger) { l.Info("abcdefghij", "klmnopqrst", "uvwxyz") },
+			`msg="abcde…[truncated 5B] klmno…[truncated 5B] uv…[truncated 4B]"`,
+		},
+		{
+			"within limits", 5, 20,
+			func(l logging.Logger) { l.Infof("%s %d", "abc", 42) },
+			`msg="abc 42"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			backend := stream.NewLogger(buffer, stream.WithEncoder(&stream.LogfmtEncoder{}), stream.WithCaller(logging.CallerOff))
+			backend.SetLevel(logging.LevelInfo)
+			test.log(NewLogger(backend, WithMaxArgSize(test.arg), WithMaxEntrySize(test.size)))
+
+			if got := strings.TrimSpace(buffer.String()); !strings.HasSuffix(got, " level=info "+test.want) {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+			if strings.Count(buffer.String(), "…[truncated") != strings.Count(test.want, "…[truncated") {
+				t.Errorf("nested markers: %s", buffer.String())
+			}
+		})
+	}
+}
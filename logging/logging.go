@@ -2,9 +2,16 @@ package logging
 
 import (
 	"sync"
+	"sync/atomic"
 )
 
-// Level represents the logging level.
+// Level represents the logging level; levels are ordered by severity, so
+// that a message is written if its level is at or above the configured one.
+//
+// Note that LevelPanic and LevelFatal precede LevelOff, whose value is 7
+// since their introduction (it was 5 before); levels stored as numbers
+// must be migrated, whereas those stored as names (see ParseLevel and
+// MarshalText) are unaffected.
 type Level uint8
 
 const (
@@ -13,6 +20,8 @@ const (
 	LevelInfo
 	LevelWarn
 	LevelError
+	LevelPanic
+	LevelFatal
 	LevelOff
 )
 
@@ -24,45 +33,73 @@ type Logger interface {
 	GetLevel() *Level
 	// ResetLevel removes the per-logger level from this specific Logger, if present.
 	ResetLevel()
+	// Enabled returns whether messages at the given level would be written by this Logger;
+	// it can be used to avoid building expensive arguments that would be discarded.
+	Enabled(level Level) bool
 	// Trace sends out a debug message with the given arguments to the logger.
 	Trace(args ...interface{})
 	// Tracef formats a debug message using the given arguments and sends it to the logger.
 	Tracef(format string, args ...interface{})
+	// Tracew sends out a debug message with the given key/value pairs to the logger.
+	Tracew(msg string, keysAndValues ...interface{})
 	// Debug sends out a debug message with the given arguments to the logger.
 	Debug(args ...interface{})
 	// Debugf formats a debug message using the given arguments and sends it to the logger.
 	Debugf(format string, args ...interface{})
+	// Debugw sends out a debug message with the given key/value pairs to the logger.
+	Debugw(msg string, keysAndValues ...interface{})
 	// Info sends out an informational message with the given arguments to the logger.
 	Info(args ...interface{})
 	// Infof formats an informational message using the given arguments and sends it to the logger.
 	Infof(format string, args ...interface{})
+	// Infow sends out an informational message with the given key/value pairs to the logger.
+	Infow(msg string, keysAndValues ...interface{})
 	// Warn sends out a warning message with the given arguments to the logger.
 	Warn(args ...interface{})
 	// Warnf formats a warning message using the given arguments and sends it to the logger.
 	Warnf(format string, args ...interface{})
+	// Warnw sends out a warning message with the given key/value pairs to the logger.
+	Warnw(msg string, keysAndValues ...interface{})
 	// Error sends out an error message with the given arguments to the logger.
 	Error(args ...interface{})
 	// Errorf formats an error message using the given arguments and sends it to the logger.
 	Errorf(format string, args ...interface{})
+	// Errorw sends out an error message with the given key/value pairs to the logger.
+	Errorw(msg string, keysAndValues ...interface{})
+	// Panic sends out a panic message with the given arguments to the logger, then panics.
+	Panic(args ...interface{})
+	// Panicf formats a panic message using the given arguments and sends it to the logger, then panics.
+	Panicf(format string, args ...interface{})
+	// Panicw sends out a panic message with the given key/value pairs to the logger, then panics.
+	Panicw(msg string, keysAndValues ...interface{})
+	// Fatal sends out a fatal message with the given arguments to the logger, flushes it and exits.
+	Fatal(args ...interface{})
+	// Fatalf formats a fatal message using the given arguments and sends it to the logger, flushes it and exits.
+	Fatalf(format string, args ...interface{})
+	// Fatalw sends out a fatal message with the given key/value pairs to the logger, flushes it and exits.
+	Fatalw(msg string, keysAndValues ...interface{})
+	// With returns a child Logger that adds the given key/value pairs to every message.
+	With(keysAndValues ...interface{}) Logger
+	// Named returns a child Logger whose name is the given name appended to this Logger's name.
+	Named(name string) Logger
 }
 
-var (
-	lock1 sync.RWMutex
-	level Level = LevelDebug
-)
+// global holds the global logging level; it is read atomically on every
+// logging call by loggers that have no level of their own.
+var global atomic.Uint32
+
+func init() {
+	global.Store(uint32(LevelDebug))
+}
 
-// SetLGlobalevel sets the logging level globally.
+// SetGlobalLevel sets the logging level globally.
 func SetGlobalLevel(l Level) {
-	lock1.Lock()
-	defer lock1.Unlock()
-	level = l
+	global.Store(uint32(l))
 }
 
 // GetGlobalLevel retrieves the current global logging level.
 func GetGlobalLevel() Level {
-	lock1.RLock()
-	defer lock1.RUnlock()
-	return level
+	return Level(global.Load())
 }
 
 var (
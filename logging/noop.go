@@ -1,7 +1,11 @@
 package logging
 
+import "fmt"
+
 // NoOpLogger is a logger that writes nothing.
-type NoOpLogger struct{}
+type NoOpLogger struct {
+	noexit bool
+}
 
 // SetLevel does nothing.
 func (l *NoOpLogger) SetLevel(_ Level) {}
@@ -12,32 +16,91 @@ func (l *NoOpLogger) GetLevel() *Level { return nil }
 // ResetLevel does nothing.
 func (l *NoOpLogger) ResetLevel() {}
 
+// Enabled always returns false.
+func (l *NoOpLogger) Enabled(_ Level) bool { return false }
+
+// With returns the NoOpLogger itself.
+func (l *NoOpLogger) With(keysAndValues ...interface{}) Logger { return l }
+
+// Named returns the NoOpLogger itself.
+func (l *NoOpLogger) Named(name string) Logger { return l }
+
+// AddCallerSkip returns the NoOpLogger itself.
+func (l *NoOpLogger) AddCallerSkip(skip int) Logger { return l }
+
+// WithCallerPC returns the NoOpLogger itself.
+func (l *NoOpLogger) WithCallerPC(pc uintptr) Logger { return l }
+
+// WithoutExit returns a NoOpLogger whose Fatal methods do nothing.
+func (l *NoOpLogger) WithoutExit() Logger { return &NoOpLogger{noexit: true} }
+
 // Trace logs a message at LevelTrace level.
 func (*NoOpLogger) Trace(args ...interface{}) {}
 
 // Tracef logs a message at LevelTrace level.
 func (*NoOpLogger) Tracef(format string, args ...interface{}) {}
 
+// Tracew logs a message at LevelTrace level.
+func (*NoOpLogger) Tracew(msg string, keysAndValues ...interface{}) {}
+
 // Debug logs a message at LevelDebug level.
 func (*NoOpLogger) Debug(args ...interface{}) {}
 
 // Debugf logs a message at LevelDebug level.
 func (*NoOpLogger) Debugf(format string, args ...interface{}) {}
 
+// Debugw logs a message at LevelDebug level.
+func (*NoOpLogger) Debugw(msg string, keysAndValues ...interface{}) {}
+
 // Info logs a message at LevelInfo level.
 func (*NoOpLogger) Info(args ...interface{}) {}
 
 // Infof logs a message at LevelInfo level.
 func (*NoOpLogger) Infof(format string, args ...interface{}) {}
 
+// Infow logs a message at LevelInfo level.
+func (*NoOpLogger) Infow(msg string, keysAndValues ...interface{}) {}
+
 // Warn logs a message at LevelWarn level.
 func (*NoOpLogger) Warn(args ...interface{}) {}
 
 // Warnf logs a message at LevelWarn level.
 func (*NoOpLogger) Warnf(format string, args ...interface{}) {}
 
+// Warnw logs a message at LevelWarn level.
+func (*NoOpLogger) Warnw(msg string, keysAndValues ...interface{}) {}
+
 // Error logs a message at LevelError level.
 func (*NoOpLogger) Error(args ...interface{}) {}
 
 // Errorf logs a message at LevelError level.
 func (*NoOpLogger) Errorf(format string, args ...interface{}) {}
+
+// Errorw logs a message at LevelError level.
+func (*NoOpLogger) Errorw(msg string, keysAndValues ...interface{}) {}
+
+// Panic panics.
+func (*NoOpLogger) Panic(args ...interface{}) { panic(fmt.Sprint(args...)) }
+
+// Panicf panics.
+func (*NoOpLogger) Panicf(format string, args ...interface{}) { panic(fmt.Sprintf(format, args...)) }
+
+// Panicw panics.
+func (*NoOpLogger) Panicw(msg string, keysAndValues ...interface{}) { panic(msg) }
+
+// Fatal exits the application.
+func (l *NoOpLogger) Fatal(args ...interface{}) { l.exit() }
+
+// Fatalf exits the application.
+func (l *NoOpLogger) Fatalf(format string, args ...interface{}) { l.exit() }
+
+// Fatalw exits the application.
+func (l *NoOpLogger) Fatalw(msg string, keysAndValues ...interface{}) { l.exit() }
+
+// exit terminates the application, unless the NoOpLogger was derived with
+// WithoutExit.
+func (l *NoOpLogger) exit() {
+	if !l.noexit {
+		Exit(1)
+	}
+}
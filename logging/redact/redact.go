@@ -0,0 +1,344 @@
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unsafe"
+
+	"github.com/dihedron/go-log-facade/logging"
+)
+
+// Rule replaces the text matching a pattern; the replacement can refer
+// to submatches, as in regexp.Regexp.ReplaceAllString.
+type Rule struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// Predefined rules for common secrets and personal data.
+var (
+	// BearerToken masks the token in "Bearer <token>" authorisation values.
+	BearerToken = Rule{
+		Pattern:     regexp.MustCompile(`(?i)\b(bearer)\s+[a-z0-9\-._~+/]+=*`),
+		Replacement: "$1 " + logging.RedactedText,
+	}
+	// AWSAccessKey masks AWS access key IDs.
+	AWSAccessKey = Rule{
+		Pattern:     regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[0-9A-Z]{16}\b`),
+		Replacement: logging.RedactedText,
+	}
+	// AWSSecretKey masks AWS secret access keys given as key/value text,
+	// e.g. aws_secret_access_key=...
+	AWSSecretKey = Rule{
+		Pattern:     regexp.MustCompile(`(?i)(aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?)[A-Za-z0-9/+=]{40}`),
+		Replacement: "${1}" + logging.RedactedText,
+	}
+	// CreditCard masks sequences of 13 to 19 digits, optionally separated
+	// by blanks or dashes, such as credit card numbers.
+	CreditCard = Rule{
+		Pattern:     regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		Replacement: logging.RedactedText,
+	}
+	// Email masks e-mail addresses.
+	Email = Rule{
+		Pattern:     regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+		Replacement: logging.RedactedText,
+	}
+)
+
+// DefaultRules are the rules applied unless WithRules is used.
+var DefaultRules = []Rule{BearerToken, AWSAccessKey, AWSSecretKey, CreditCard, Email}
+
+// DefaultFields are commonly sensitive field names, redacted unless
+// WithFields is used; they can also be passed to logging.SetRedactedFields.
+var DefaultFields = []string{"password", "passwd", "secret", "token", "api_key", "apikey", "authorization", "cookie"}
+
+type config struct {
+	rules  []Rule
+	fields map[string]bool
+}
+
+// Option is a functional option for configuring a redacting Logger.
+type Option func(*config)
+
+// WithRules sets the rules applied to messages and string values,
+// replacing DefaultRules.
+func WithRules(rules ...Rule) Option {
+	return func(c *config) {
+		c.rules = rules
+	}
+}
+
+// WithFields sets the names of the fields whose values are redacted,
+// replacing DefaultFields: keys of key/value pairs, keys of maps, names of
+// struct fields (or their JSON tags) and keys of JSON documents passed as
+// strings, e.g. produced by logging.ToJSON; names are compared
+// case-insensitively.
+func WithFields(names ...string) Option {
+	return func(c *config) {
+		c.fields = fieldSet(names)
+	}
+}
+
+// fieldSet returns the set of the given field names, in lower case.
+func fieldSet(names []string) map[string]bool {
+	fields := make(map[string]bool, len(names))
+	for _, name := range names {
+		fields[strings.ToLower(name)] = true
+	}
+	return fields
+}
+
+// Logger is a logger that removes secrets and personal data from entries
+// before passing them to the wrapped Logger: messages and string values
+// are masked according to a set of rules, and the values of fields with
+// sensitive names are replaced by logging.RedactedText. Printf-style
+// entries are formatted before redaction, so that patterns spanning the
+// format and its arguments are matched. Values wrapped in
+// logging.Redacted are never revealed by any backend.
+type Logger struct {
+	logging.Wrapper
+	config *config
+}
+
+// NewLogger returns a redacting Logger wrapping the given Logger.
+func NewLogger(logger logging.Logger, options ...Option) *Logger {
+	c := &config{
+		rules:  DefaultRules,
+		fields: fieldSet(DefaultFields),
+	}
+	for _, option := range options {
+		option(c)
+	}
+	l := &Logger{config: c}
+	l.Wrapper = logging.NewWrapper(logger, l, l.derive)
+	return l
+}
+
+// derive returns a child Logger with the same configuration.
+func (l *Logger) derive(w logging.Wrapper) logging.Logger {
+	child := &Logger{config: l.config}
+	child.Wrapper = w.Bind(child, child.derive)
+	return child
+}
+
+// With returns a child Logger that adds the given key/value pairs, once
+// redacted, to every message; it shares the logging level of its parent.
+func (l *Logger) With(keysAndValues ...interface{}) logging.Logger {
+	return l.Derive(l.Unwrap().With(l.redactPairs(keysAndValues)...))
+}
+
+// Handle redacts the entry and writes it to the wrapped Logger.
+func (l *Logger) Handle(entry *logging.Entry) {
+	redacted := &logging.Entry{
+		Time:  entry.Time,
+		Level: entry.Level,
+		Style: entry.Style,
+		PC:    entry.PC,
+		Stack: entry.Stack,
+	}
+	switch entry.Style {
+	case logging.StylePrint:
+		redacted.Args = []interface{}{l.redactString(logging.Sprint(l.redactValues(entry.Args)...))}
+	case logging.StylePrintf:
+		redacted.Format = "%s"
+		redacted.Args = []interface{}{l.redactString(logging.Sprintf(entry.Format, l.redactValues(entry.Args)...))}
+	case logging.StylePrintw:
+		redacted.Format = l.redactString(entry.Format)
+		redacted.Args = l.redactPairs(entry.Args)
+	}
+	redacted.Replay(l.Unwrap())
+}
+
+// redactPairs redacts the values of a list of key/value pairs.
+func (l *Logger) redactPairs(keysAndValues []interface{}) []interface{} {
+	redacted := make([]interface{}, len(keysAndValues))
+	for i := 0; i < len(keysAndValues); i++ {
+		key, ok := keysAndValues[i].(string)
+		if !ok || i == len(keysAndValues)-1 {
+			redacted[i] = l.redactValue(keysAndValues[i], 0)
+			continue
+		}
+		redacted[i] = key
+		if l.config.fields[strings.ToLower(key)] {
+			redacted[i+1] = logging.RedactedText
+		} else {
+			redacted[i+1] = l.redactValue(keysAndValues[i+1], 0)
+		}
+		i++
+	}
+	return redacted
+}
+
+func (l *Logger) redactValues(args []interface{}) []interface{} {
+	redacted := make([]interface{}, len(args))
+	for i, arg := range args {
+		redacted[i] = l.redactValue(arg, 0)
+	}
+	return redacted
+}
+
+// maxDepth limits the recursion into nested values, which may be cyclic.
+const maxDepth = 8
+
+// redactValue returns the value with its sensitive parts redacted; values
+// that need no redaction are returned as they are, so that backends still
+// see their original type.
+func (l *Logger) redactValue(value interface{}, depth int) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case logging.Lazy:
+		return l.redactValue(v(), depth)
+	case func() interface{}:
+		return l.redactValue(v(), depth)
+	case logging.Redacted, logging.StackTrace:
+		return v
+	case string:
+		return l.redactText(v)
+	case error:
+		if s := l.redactString(v.Error()); s != v.Error() {
+			return s
+		}
+		return v
+	case fmt.Stringer:
+		if s := l.redactString(v.String()); s != v.String() {
+			return s
+		}
+		return v
+	}
+	if depth >= maxDepth {
+		return value
+	}
+	return l.redactStructure(reflect.ValueOf(value), depth).Interface()
+}
+
+// redactText masks the string and, if it holds a JSON document, the values
+// of its sensitive fields.
+func (l *Logger) redactText(s string) string {
+	trimmed := strings.TrimSpace(s)
+	if len(l.config.fields) > 0 && len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid([]byte(trimmed)) {
+		names := make([]string, 0, len(l.config.fields))
+		for name := range l.config.fields {
+			names = append(names, name)
+		}
+		s = string(logging.RedactJSON([]byte(trimmed), names...))
+	}
+	return l.redactString(s)
+}
+
+// redactStructure returns a copy of strings, maps, slices, arrays, structs
+// and pointers to structs, of the same type, in which the strings have been
+// masked and the sensitive fields, exported or not, replaced by
+// logging.RedactedText, or by their zero value if they are not strings.
+func (l *Logger) redactStructure(value reflect.Value, depth int) reflect.Value {
+	if depth >= maxDepth {
+		return value
+	}
+	switch value.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(l.redactString(value.String())).Convert(value.Type())
+	case reflect.Pointer:
+		if value.IsNil() || value.Elem().Kind() != reflect.Struct {
+			return value
+		}
+		copied := reflect.New(value.Elem().Type())
+		copied.Elem().Set(l.redactStructure(value.Elem(), depth+1))
+		return copied
+	case reflect.Interface:
+		if value.IsNil() || !value.Elem().CanInterface() {
+			return value
+		}
+		if redacted := reflect.ValueOf(l.redactValue(value.Elem().Interface(), depth+1)); redacted.IsValid() && redacted.Type().AssignableTo(value.Type()) {
+			return redacted
+		}
+		return value
+	case reflect.Map:
+		if value.IsNil() || value.Type().Key().Kind() != reflect.String {
+			return value
+		}
+		copied := reflect.MakeMapWithSize(value.Type(), value.Len())
+		for iter := value.MapRange(); iter.Next(); {
+			if l.config.fields[strings.ToLower(iter.Key().String())] {
+				copied.SetMapIndex(iter.Key(), redactedValue(value.Type().Elem()))
+			} else {
+				copied.SetMapIndex(iter.Key(), l.redactStructure(iter.Value(), depth+1))
+			}
+		}
+		return copied
+	case reflect.Slice:
+		if value.IsNil() || !composite(value.Type().Elem()) {
+			return value
+		}
+		copied := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		for i := 0; i < value.Len(); i++ {
+			copied.Index(i).Set(l.redactStructure(value.Index(i), depth+1))
+		}
+		return copied
+	case reflect.Array:
+		if !composite(value.Type().Elem()) {
+			return value
+		}
+		copied := reflect.New(value.Type()).Elem()
+		for i := 0; i < value.Len(); i++ {
+			copied.Index(i).Set(l.redactStructure(value.Index(i), depth+1))
+		}
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(value.Type()).Elem()
+		copied.Set(value)
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			target := copied.Field(i)
+			if !field.IsExported() {
+				// the copy is addressable, so its unexported fields can
+				// be read and written through their address, since they
+				// are formatted by %+v and the like
+				target = reflect.NewAt(field.Type, unsafe.Pointer(target.UnsafeAddr())).Elem()
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if l.config.fields[strings.ToLower(field.Name)] || (name != "" && l.config.fields[strings.ToLower(name)]) {
+				target.Set(redactedValue(field.Type))
+			} else {
+				target.Set(l.redactStructure(target, depth+1))
+			}
+		}
+		return copied
+	}
+	return value
+}
+
+// redactedValue returns logging.RedactedText as a value of the given type
+// if possible, its zero value otherwise.
+func redactedValue(t reflect.Type) reflect.Value {
+	switch t.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(logging.RedactedText).Convert(t)
+	case reflect.Interface:
+		if reflect.TypeOf(logging.RedactedText).AssignableTo(t) {
+			return reflect.ValueOf(logging.RedactedText)
+		}
+	}
+	return reflect.Zero(t)
+}
+
+// composite returns whether values of the given type may hold strings or
+// named fields.
+func composite(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Interface, reflect.Map, reflect.Slice, reflect.Array, reflect.Struct, reflect.Pointer:
+		return true
+	}
+	return false
+}
+
+// redactString applies the rules to the string.
+func (l *Logger) redactString(s string) string {
+	for _, rule := range l.config.rules {
+		s = rule.Pattern.ReplaceAllString(s, rule.Replacement)
+	}
+	return s
+}
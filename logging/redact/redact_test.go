@@ -0,0 +1,69 @@
+package redact
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dihedron/go-log-facade/logging"
+	"github.com/dihedron/go-log-facade/logging/stream"
+)
+
+type inner struct {
+	Password string
+}
+
+type cfg struct {
+	User   string
+	token  string
+	hidden inner
+	Nested [1]inner
+	extra  map[string]interface{}
+}
+
+func TestStructuresAreRedacted(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	backend := stream.NewLogger(buffer, stream.WithEncoder(&stream.LogfmtEncoder{}), stream.WithCaller(logging.CallerOff))
+	backend.SetLevel(logging.LevelInfo)
+	logger := NewLogger(backend)
+
+	value := cfg{
+		User:   "admin",
+		token:  "t0ken",
+		hidden: inner{Password: "h1dden"},
+		Nested: [1]inner{{Password: "inner"}},
+		extra:  map[string]interface{}{"secret": "s3cret", "mail": "user@example.com"},
+	}
+	logger.Infof("cfg: %+v", value)
+	logger.Infow("cfg", "value", value, "pointer", &value)
+
+	output := buffer.String()
+	for _, secret := range []string{"t0ken", "h1dden", "inner", "s3cret", "user@example.com"} {
+		if strings.Contains(output, secret) {
+			t.Errorf("%q was not redacted:\n%s", secret, output)
+		}
+	}
+	if !strings.Contains(output, "admin") {
+		t.Errorf("non-sensitive field was redacted:\n%s", output)
+	}
+	if value.token != "t0ken" || value.Nested[0].Password != "inner" || value.extra["secret"] != "s3cret" {
+		t.Errorf("the original value was modified: %+v", value)
+	}
+}
+
+func TestDefaultFields(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	backend := stream.NewLogger(buffer, stream.WithEncoder(&stream.LogfmtEncoder{}), stream.WithCaller(logging.CallerOff))
+	backend.SetLevel(logging.LevelInfo)
+
+	NewLogger(backend).Infow("login", "user", "admin", "password", "pa55")
+	NewLogger(backend, WithFields("user")).Infow("login", "user", "admin", "password", "pa55")
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if !strings.HasSuffix(lines[0], "user=admin password="+logging.RedactedText) {
+		t.Errorf("DefaultFields are not redacted by default: %s", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "user="+logging.RedactedText+" password=pa55") {
+		t.Errorf("WithFields does not replace DefaultFields: %s", lines[1])
+	}
+}
@@ -0,0 +1,191 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RedactedText is what is written in place of redacted values.
+const RedactedText = "[REDACTED]"
+
+// Redacted wraps a sensitive value (e.g. a password or an API key) so
+// that it can be passed around, and even logged by mistake, without its
+// content ever being written: it formats, marshals to JSON, YAML and text
+// as RedactedText, also when nested in other values. The value is held in
+// a closure, so that not even reflection-based printing of unexported
+// fields can reveal it.
+type Redacted struct {
+	value func() interface{}
+}
+
+// Redact wraps the given value.
+func Redact(value interface{}) Redacted {
+	return Redacted{value: func() interface{} { return value }}
+}
+
+// Value returns the wrapped value, for use by the code that needs it.
+func (r Redacted) Value() interface{} {
+	if r.value == nil {
+		return nil
+	}
+	return r.value()
+}
+
+// String implements fmt.Stringer.
+func (r Redacted) String() string {
+	return RedactedText
+}
+
+// GoString implements fmt.GoStringer, so that %#v does not reveal the
+// value either.
+func (r Redacted) GoString() string {
+	return RedactedText
+}
+
+// Format implements fmt.Formatter, so that all verbs print RedactedText.
+func (r Redacted) Format(f fmt.State, verb rune) {
+	_, _ = f.Write([]byte(RedactedText))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (r Redacted) MarshalText() ([]byte, error) {
+	return []byte(RedactedText), nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r Redacted) MarshalJSON() ([]byte, error) {
+	return json.Marshal(RedactedText)
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (r Redacted) MarshalYAML() (interface{}, error) {
+	return RedactedText, nil
+}
+
+var (
+	lock4          sync.RWMutex
+	redactedFields map[string]bool
+)
+
+// SetRedactedFields sets the names of the fields whose values ToJSON,
+// ToPrettyJSON and ToYAML replace with RedactedText, at any depth; names
+// are compared case-insensitively. Calling it with no names disables
+// redaction.
+func SetRedactedFields(names ...string) {
+	fields := map[string]bool{}
+	for _, name := range names {
+		fields[strings.ToLower(name)] = true
+	}
+	lock4.Lock()
+	defer lock4.Unlock()
+	if len(fields) == 0 {
+		redactedFields = nil
+	} else {
+		redactedFields = fields
+	}
+}
+
+// getRedactedFields returns the current set of redacted field names, or
+// nil if there is none.
+func getRedactedFields() map[string]bool {
+	lock4.RLock()
+	defer lock4.RUnlock()
+	return redactedFields
+}
+
+// RedactJSON returns the given JSON document with the values of the
+// fields having one of the given names (compared case-insensitively)
+// replaced by RedactedText, at any depth and preserving the order of the
+// fields; the output is compact. Invalid JSON is returned unchanged.
+func RedactJSON(data []byte, names ...string) []byte {
+	fields := map[string]bool{}
+	for _, name := range names {
+		fields[strings.ToLower(name)] = true
+	}
+	return redactJSON(data, fields)
+}
+
+func redactJSON(data []byte, fields map[string]bool) []byte {
+	var buffer bytes.Buffer
+	if err := redactJSONValue(&buffer, data, fields); err != nil {
+		return data
+	}
+	return buffer.Bytes()
+}
+
+func redactJSONValue(buffer *bytes.Buffer, data []byte, fields map[string]bool) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || (data[0] != '{' && data[0] != '[') {
+		return json.Compact(buffer, data)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+	object := data[0] == '{'
+	if object {
+		buffer.WriteByte('{')
+	} else {
+		buffer.WriteByte('[')
+	}
+	for i := 0; decoder.More(); i++ {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		redacted := false
+		if object {
+			token, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			key, _ := token.(string)
+			name, _ := json.Marshal(key)
+			buffer.Write(name)
+			buffer.WriteByte(':')
+			redacted = fields[strings.ToLower(key)]
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return err
+		}
+		if redacted {
+			text, _ := json.Marshal(RedactedText)
+			buffer.Write(text)
+		} else if err := redactJSONValue(buffer, value, fields); err != nil {
+			return err
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+	if object {
+		buffer.WriteByte('}')
+	} else {
+		buffer.WriteByte(']')
+	}
+	return nil
+}
+
+// redactYAML replaces the values of the matching keys in the YAML node
+// tree, at any depth.
+func redactYAML(node *yaml.Node, fields map[string]bool) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if fields[strings.ToLower(node.Content[i].Value)] {
+				node.Content[i+1] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: RedactedText}
+				continue
+			}
+			redactYAML(node.Content[i+1], fields)
+		}
+		return
+	}
+	for _, child := range node.Content {
+		redactYAML(child, fields)
+	}
+}
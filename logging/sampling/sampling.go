@@ -0,0 +1,263 @@
+package sampling
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/dihedron/go-log-facade/logging"
+)
+
+type config struct {
+	first      int
+	thereafter int
+	tick       time.Duration
+	limits     map[logging.Level]limit
+	interval   time.Duration
+}
+
+type limit struct {
+	rate  float64
+	burst int
+}
+
+// Option is a functional option for configuring a sampling Logger.
+type Option func(*config)
+
+// WithSampling logs the first entries with the same level and message
+// template (i.e. the format string, or the message for Print-style
+// calls) in every tick, and then only one every thereafter entries;
+// if thereafter is zero, all subsequent entries in the tick are dropped.
+// If tick is not positive, it defaults to one second.
+func WithSampling(first int, thereafter int, tick time.Duration) Option {
+	return func(c *config) {
+		c.first = first
+		c.thereafter = thereafter
+		c.tick = tick
+		if c.tick <= 0 {
+			c.tick = time.Second
+		}
+	}
+}
+
+// WithRateLimit limits entries at the given level to rate per second on
+// average, allowing bursts of up to burst entries.
+func WithRateLimit(level logging.Level, rate float64, burst int) Option {
+	return func(c *config) {
+		c.limits[level] = limit{rate: rate, burst: burst}
+	}
+}
+
+// WithReportInterval sets how often the number of suppressed entries is
+// reported through the wrapped Logger; the default is one minute.
+func WithReportInterval(interval time.Duration) Option {
+	return func(c *config) {
+		c.interval = interval
+	}
+}
+
+// key identifies the entries considered similar by the sampler.
+type key struct {
+	level    logging.Level
+	template string
+}
+
+type counter struct {
+	count      int
+	reset      time.Time
+	suppressed uint64
+}
+
+type bucket struct {
+	limit      limit
+	tokens     float64
+	last       time.Time
+	suppressed uint64
+}
+
+// sampler holds the state shared by a sampling Logger and its children.
+type sampler struct {
+	config   *config
+	lock     sync.Mutex
+	counters map[key]*counter
+	pruned   time.Time
+	buckets  map[logging.Level]*bucket
+	report   func(format string, args ...interface{})
+	stop     chan struct{}
+	wg       sync.WaitGroup
+	once     sync.Once
+}
+
+// Logger is a logger that samples and rate limits the entries passed to
+// the wrapped Logger, so that a flood of similar messages cannot swamp
+// it; the number of suppressed entries is reported periodically. Panic
+// and fatal entries are never suppressed.
+type Logger struct {
+	logging.Wrapper
+	sampler *sampler
+}
+
+// NewLogger returns a sampling Logger wrapping the given Logger; it must
+// be closed to stop reporting.
+func NewLogger(logger logging.Logger, options ...Option) *Logger {
+	c := &config{
+		limits:   map[logging.Level]limit{},
+		interval: time.Minute,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	s := &sampler{
+		config:   c,
+		counters: map[key]*counter{},
+		buckets:  map[logging.Level]*bucket{},
+		report:   logger.Warnf,
+		stop:     make(chan struct{}),
+	}
+	for level, limit := range c.limits {
+		s.buckets[level] = &bucket{limit: limit, tokens: float64(limit.burst), last: time.Now()}
+	}
+	if c.interval > 0 {
+		s.wg.Add(1)
+		go s.reportEvery(c.interval)
+	}
+	l := &Logger{sampler: s}
+	l.Wrapper = logging.NewWrapper(logger, l, l.derive)
+	return l
+}
+
+// derive returns a child Logger sharing the sampling state.
+func (l *Logger) derive(w logging.Wrapper) logging.Logger {
+	child := &Logger{sampler: l.sampler}
+	child.Wrapper = w.Bind(child, child.derive)
+	return child
+}
+
+// Handle writes the entry to the wrapped Logger unless it is suppressed.
+func (l *Logger) Handle(entry *logging.Entry) {
+	if entry.Level >= logging.LevelPanic || l.sampler.allow(entry, time.Now()) {
+		entry.Replay(l.Unwrap())
+	}
+}
+
+// Close reports the entries suppressed since the last report and stops
+// the periodic reporting; it closes the wrapped Logger, if it supports it.
+func (l *Logger) Close() error {
+	l.sampler.once.Do(func() {
+		close(l.sampler.stop)
+		l.sampler.wg.Wait()
+		l.sampler.flush(time.Now())
+	})
+	if closer, ok := l.Unwrap().(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// allow returns whether the entry, logged at the given time, passes both
+// the sampler and the rate limiter of its level.
+func (s *sampler) allow(entry *logging.Entry, now time.Time) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.config.first > 0 || s.config.thereafter > 0 {
+		s.prune(now)
+		template := entry.Format
+		if entry.Style == logging.StylePrint {
+			template = entry.Message()
+		}
+		k := key{level: entry.Level, template: template}
+		c, ok := s.counters[k]
+		if !ok {
+			c = &counter{}
+			s.counters[k] = c
+		}
+		if !now.Before(c.reset) {
+			c.count = 0
+			c.reset = now.Add(s.config.tick)
+		}
+		c.count++
+		if c.count > s.config.first &&
+			(s.config.thereafter <= 0 || (c.count-s.config.first)%s.config.thereafter != 0) {
+			c.suppressed++
+			return false
+		}
+	}
+	if b, ok := s.buckets[entry.Level]; ok {
+		b.tokens += now.Sub(b.last).Seconds() * b.limit.rate
+		if b.tokens > float64(b.limit.burst) {
+			b.tokens = float64(b.limit.burst)
+		}
+		b.last = now
+		if b.tokens < 1 {
+			b.suppressed++
+			return false
+		}
+		b.tokens--
+	}
+	return true
+}
+
+// prune forgets the counters that have expired, at most once per tick,
+// so that the number of counters stays bounded even if nothing is
+// reported; counters with suppressed entries are kept until the next
+// report, if any. It must be called with the lock held.
+func (s *sampler) prune(now time.Time) {
+	if now.Before(s.pruned.Add(s.config.tick)) {
+		return
+	}
+	s.pruned = now
+	for k, c := range s.counters {
+		if !now.Before(c.reset) && (c.suppressed == 0 || s.config.interval <= 0) {
+			delete(s.counters, k)
+		}
+	}
+}
+
+func (s *sampler) reportEvery(interval time.Duration) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			s.flush(now)
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// flush reports the suppressed entries and forgets the counters that
+// have expired.
+func (s *sampler) flush(now time.Time) {
+	type report struct {
+		key        key
+		suppressed uint64
+		limited    bool
+	}
+	reports := []report{}
+	s.lock.Lock()
+	for k, c := range s.counters {
+		if c.suppressed > 0 {
+			reports = append(reports, report{key: k, suppressed: c.suppressed})
+			c.suppressed = 0
+		}
+		if !now.Before(c.reset) {
+			delete(s.counters, k)
+		}
+	}
+	for level, b := range s.buckets {
+		if b.suppressed > 0 {
+			reports = append(reports, report{key: key{level: level}, suppressed: b.suppressed, limited: true})
+			b.suppressed = 0
+		}
+	}
+	s.lock.Unlock()
+	for _, r := range reports {
+		if r.limited {
+			s.report("suppressed %d %s messages by rate limit", r.suppressed, r.key.level)
+		} else {
+			s.report("suppressed %d similar %s messages: %q", r.suppressed, r.key.level, r.key.template)
+		}
+	}
+}
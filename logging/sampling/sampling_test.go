@@ -0,0 +1,53 @@
+package sampling
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dihedron/go-log-facade/logging"
+	"github.com/dihedron/go-log-facade/logging/stream"
+)
+
+func TestSamplingWithZeroTick(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	inner := stream.NewLogger(buffer, stream.WithEncoder(&stream.LogfmtEncoder{}))
+	inner.SetLevel(logging.LevelInfo)
+	logger := NewLogger(inner, WithSampling(2, 0, 0), WithReportInterval(0))
+	defer logger.Close()
+
+	for i := 0; i < 10; i++ {
+		logger.Infof("message %d", i)
+	}
+	if lines := strings.Count(buffer.String(), "\n"); lines != 2 {
+		t.Errorf("got %d lines, want 2:\n%s", lines, buffer.String())
+	}
+}
+
+func TestCountersArePruned(t *testing.T) {
+	for _, interval := range []time.Duration{0, time.Hour} {
+		t.Run(fmt.Sprint(interval), func(t *testing.T) {
+			logger := NewLogger(&logging.NoOpLogger{}, WithSampling(1, 0, time.Second), WithReportInterval(interval))
+			defer logger.Close()
+			s := logger.sampler
+
+			now := time.Now()
+			for i := 0; i < 1000; i++ {
+				s.allow(&logging.Entry{Level: logging.LevelInfo, Style: logging.StylePrint, Args: []interface{}{i}}, now)
+			}
+			s.allow(&logging.Entry{Level: logging.LevelInfo, Style: logging.StylePrint, Args: []interface{}{0}}, now)
+			s.allow(&logging.Entry{Level: logging.LevelInfo, Style: logging.StylePrint, Args: []interface{}{"other"}}, now.Add(2*time.Second))
+
+			want := 1
+			if interval > 0 {
+				// the counter with a suppressed entry waits for the report
+				want = 2
+			}
+			if len(s.counters) != want {
+				t.Errorf("%d counters after they expired, want %d", len(s.counters), want)
+			}
+		})
+	}
+}
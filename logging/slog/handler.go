@@ -0,0 +1,109 @@
+package slog
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/dihedron/go-log-facade/logging"
+)
+
+// Handler is a slog.Handler that forwards records to a logging.Logger,
+// so that code using log/slog writes to the same backend as code using
+// the facade. Attributes are passed as key/value pairs, with the keys
+// of grouped attributes qualified by the group names (e.g. "group.key").
+type Handler struct {
+	logger logging.Logger
+	prefix string
+}
+
+// NewHandler returns a slog.Handler writing to the given Logger.
+func NewHandler(logger logging.Logger) *Handler {
+	return &Handler{
+		logger: logger,
+	}
+}
+
+// Enabled reports whether the underlying Logger writes messages at the
+// given level.
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.Enabled(FromSlogLevel(level))
+}
+
+// Handle forwards the record to the underlying Logger, reporting the
+// record's time and source if the Logger is a logging.TimeLogger and a
+// logging.CallerLogger respectively; records above slog.LevelError are
+// logged as errors, since a handler must neither panic nor exit the
+// application.
+func (h *Handler) Handle(_ context.Context, record slog.Record) error {
+	keysAndValues := make([]interface{}, 0, 2*record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		keysAndValues = appendAttr(keysAndValues, h.prefix, attr)
+		return true
+	})
+	logger := h.logger
+	if t, ok := logger.(logging.TimeLogger); ok && !record.Time.IsZero() {
+		logger = t.WithTime(record.Time)
+	}
+	if c, ok := logger.(logging.CallerLogger); ok && record.PC != 0 {
+		logger = c.WithCallerPC(record.PC)
+	}
+	switch FromSlogLevel(record.Level) {
+	case logging.LevelTrace:
+		logger.Tracew(record.Message, keysAndValues...)
+	case logging.LevelDebug:
+		logger.Debugw(record.Message, keysAndValues...)
+	case logging.LevelInfo:
+		logger.Infow(record.Message, keysAndValues...)
+	case logging.LevelWarn:
+		logger.Warnw(record.Message, keysAndValues...)
+	default:
+		logger.Errorw(record.Message, keysAndValues...)
+	}
+	return nil
+}
+
+// WithAttrs returns a Handler whose Logger is bound to the given attributes.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	keysAndValues := make([]interface{}, 0, 2*len(attrs))
+	for _, attr := range attrs {
+		keysAndValues = appendAttr(keysAndValues, h.prefix, attr)
+	}
+	return &Handler{
+		logger: h.logger.With(keysAndValues...),
+		prefix: h.prefix,
+	}
+}
+
+// WithGroup returns a Handler that qualifies all subsequent attribute
+// keys with the given group name.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &Handler{
+		logger: h.logger,
+		prefix: h.prefix + name + ".",
+	}
+}
+
+// appendAttr flattens the attribute into key/value pairs, recursing into
+// groups and dropping empty attributes as required by slog.
+func appendAttr(keysAndValues []interface{}, prefix string, attr slog.Attr) []interface{} {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return keysAndValues
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix = prefix + attr.Key + "."
+		}
+		for _, child := range attr.Value.Group() {
+			keysAndValues = appendAttr(keysAndValues, prefix, child)
+		}
+		return keysAndValues
+	}
+	return append(keysAndValues, prefix+attr.Key, attr.Value.Any())
+}
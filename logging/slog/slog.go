@@ -0,0 +1,352 @@
+package slog
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/dihedron/go-log-facade/logging"
+)
+
+// Custom slog levels for the facade levels that have no slog equivalent.
+const (
+	LevelTrace = slog.Level(-8)
+	LevelPanic = slog.Level(12)
+	LevelFatal = slog.Level(16)
+)
+
+// ToSlogLevel converts a facade logging level into the corresponding
+// slog level; LevelTrace, LevelPanic and LevelFatal map to the custom
+// levels defined in this package.
+func ToSlogLevel(level logging.Level) slog.Level {
+	switch level {
+	case logging.LevelTrace:
+		return LevelTrace
+	case logging.LevelDebug:
+		return slog.LevelDebug
+	case logging.LevelInfo:
+		return slog.LevelInfo
+	case logging.LevelWarn:
+		return slog.LevelWarn
+	case logging.LevelError:
+		return slog.LevelError
+	case logging.LevelPanic:
+		return LevelPanic
+	}
+	return LevelFatal
+}
+
+// FromSlogLevel converts a slog level into the facade logging level
+// having the greatest value not above it.
+func FromSlogLevel(level slog.Level) logging.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return logging.LevelTrace
+	case level < slog.LevelInfo:
+		return logging.LevelDebug
+	case level < slog.LevelWarn:
+		return logging.LevelInfo
+	case level < slog.LevelError:
+		return logging.LevelWarn
+	case level < LevelPanic:
+		return logging.LevelError
+	case level < LevelFatal:
+		return logging.LevelPanic
+	}
+	return logging.LevelFatal
+}
+
+// Logger is an adapter that allows to log using a *slog.Logger wherever
+// a Logger interface is expected.
+type Logger struct {
+	logger *slog.Logger
+	level  *logging.LevelVar
+	name   string
+	skip   int
+	pc     uintptr
+	time   time.Time
+	noexit bool
+}
+
+// NewLogger returns a Logger writing to the given slog Logger; if nil,
+// the default slog Logger is used.
+func NewLogger(logger *slog.Logger) *Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Logger{
+		logger: logger,
+		level:  logging.NewLevelVar(),
+	}
+}
+
+func (l *Logger) SetLevel(level logging.Level) {
+	l.level.Set(level)
+}
+
+func (l *Logger) GetLevel() *logging.Level {
+	// return the per-instance logging level if set, the global level otherwise
+	return l.level.Pointer()
+}
+
+func (l *Logger) ResetLevel() {
+	l.level.Reset()
+}
+
+// Enabled returns whether messages at the given level would be written,
+// according to both this Logger's level and the slog handler.
+func (l *Logger) Enabled(level logging.Level) bool {
+	return l.level.Enabled(level) && l.logger.Enabled(context.Background(), ToSlogLevel(level))
+}
+
+// With returns a child Logger that adds the given key/value pairs as
+// attributes to every message; the child shares the logging level of
+// its parent.
+func (l *Logger) With(keysAndValues ...interface{}) logging.Logger {
+	child := *l
+	child.logger = l.logger.With(keysAndValues...)
+	return &child
+}
+
+// Named returns a child Logger whose name is the given name appended
+// to the parent's name; since slog has no notion of logger names, it
+// is reported in the "logger" attribute.
+func (l *Logger) Named(name string) logging.Logger {
+	child := *l
+	if l.name != "" {
+		child.name = l.name + "." + name
+	} else {
+		child.name = name
+	}
+	return &child
+}
+
+// AddCallerSkip returns a child Logger that reports as source the function
+// skip frames further up the stack; it shares the logging level of its
+// parent.
+func (l *Logger) AddCallerSkip(skip int) logging.Logger {
+	child := *l
+	child.skip += skip
+	return &child
+}
+
+// WithCallerPC returns a child Logger that reports the given program
+// counter as the source of all its logging calls; it shares the logging
+// level of its parent.
+func (l *Logger) WithCallerPC(pc uintptr) logging.Logger {
+	child := *l
+	child.pc = pc
+	return &child
+}
+
+// WithTime returns a child Logger that reports the given time as the time
+// of all its records; it shares the logging level of its parent.
+func (l *Logger) WithTime(t time.Time) logging.Logger {
+	child := *l
+	child.time = t
+	return &child
+}
+
+// WithoutExit returns a child Logger whose Fatal methods log the message
+// and return instead of exiting; it shares the logging level of its
+// parent.
+func (l *Logger) WithoutExit() logging.Logger {
+	child := *l
+	child.noexit = true
+	return &child
+}
+
+// Trace logs a message at LevelTrace level.
+func (l *Logger) Trace(args ...interface{}) {
+	if l.level.Enabled(logging.LevelTrace) {
+		l.log(LevelTrace, logging.Sprint(args...))
+	}
+}
+
+// Tracef logs a message at LevelTrace level.
+func (l *Logger) Tracef(format string, args ...interface{}) {
+	if l.level.Enabled(logging.LevelTrace) {
+		l.log(LevelTrace, logging.Sprintf(format, args...))
+	}
+}
+
+// Tracew logs a message with the given key/value pairs at LevelTrace level.
+func (l *Logger) Tracew(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelTrace) {
+		l.log(LevelTrace, msg, keysAndValues...)
+	}
+}
+
+// Debug logs a message at LevelDebug level.
+func (l *Logger) Debug(args ...interface{}) {
+	if l.level.Enabled(logging.LevelDebug) {
+		l.log(slog.LevelDebug, logging.Sprint(args...))
+	}
+}
+
+// Debugf logs a message at LevelDebug level.
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	if l.level.Enabled(logging.LevelDebug) {
+		l.log(slog.LevelDebug, logging.Sprintf(format, args...))
+	}
+}
+
+// Debugw logs a message with the given key/value pairs at LevelDebug level.
+func (l *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelDebug) {
+		l.log(slog.LevelDebug, msg, keysAndValues...)
+	}
+}
+
+// Info logs a message at LevelInfo level.
+func (l *Logger) Info(args ...interface{}) {
+	if l.level.Enabled(logging.LevelInfo) {
+		l.log(slog.LevelInfo, logging.Sprint(args...))
+	}
+}
+
+// Infof logs a message at LevelInfo level.
+func (l *Logger) Infof(format string, args ...interface{}) {
+	if l.level.Enabled(logging.LevelInfo) {
+		l.log(slog.LevelInfo, logging.Sprintf(format, args...))
+	}
+}
+
+// Infow logs a message with the given key/value pairs at LevelInfo level.
+func (l *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelInfo) {
+		l.log(slog.LevelInfo, msg, keysAndValues...)
+	}
+}
+
+// Warn logs a message at LevelWarn level.
+func (l *Logger) Warn(args ...interface{}) {
+	if l.level.Enabled(logging.LevelWarn) {
+		l.log(slog.LevelWarn, logging.Sprint(args...))
+	}
+}
+
+// Warnf logs a message at LevelWarn level.
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	if l.level.Enabled(logging.LevelWarn) {
+		l.log(slog.LevelWarn, logging.Sprintf(format, args...))
+	}
+}
+
+// Warnw logs a message with the given key/value pairs at LevelWarn level.
+func (l *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelWarn) {
+		l.log(slog.LevelWarn, msg, keysAndValues...)
+	}
+}
+
+// Error logs a message at LevelError level.
+func (l *Logger) Error(args ...interface{}) {
+	if l.level.Enabled(logging.LevelError) {
+		l.log(slog.LevelError, logging.Sprint(args...))
+	}
+}
+
+// Errorf logs a message at LevelError level.
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	if l.level.Enabled(logging.LevelError) {
+		l.log(slog.LevelError, logging.Sprintf(format, args...))
+	}
+}
+
+// Errorw logs a message with the given key/value pairs at LevelError level.
+func (l *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelError) {
+		l.log(slog.LevelError, msg, keysAndValues...)
+	}
+}
+
+// Panic logs a message at LevelPanic level, then panics.
+func (l *Logger) Panic(args ...interface{}) {
+	message := logging.Sprint(args...)
+	if l.level.Enabled(logging.LevelPanic) {
+		l.log(LevelPanic, message)
+	}
+	panic(message)
+}
+
+// Panicf logs a message at LevelPanic level, then panics.
+func (l *Logger) Panicf(format string, args ...interface{}) {
+	message := logging.Sprintf(format, args...)
+	if l.level.Enabled(logging.LevelPanic) {
+		l.log(LevelPanic, message)
+	}
+	panic(message)
+}
+
+// Panicw logs a message with the given key/value pairs at LevelPanic
+// level, then panics.
+func (l *Logger) Panicw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelPanic) {
+		l.log(LevelPanic, msg, keysAndValues...)
+	}
+	panic(msg)
+}
+
+// Fatal logs a message at LevelFatal level, then exits.
+func (l *Logger) Fatal(args ...interface{}) {
+	if l.level.Enabled(logging.LevelFatal) {
+		l.log(LevelFatal, logging.Sprint(args...))
+	}
+	l.exit()
+}
+
+// Fatalf logs a message at LevelFatal level, then exits.
+func (l *Logger) Fatalf(format string, args ...interface{}) {
+	if l.level.Enabled(logging.LevelFatal) {
+		l.log(LevelFatal, logging.Sprintf(format, args...))
+	}
+	l.exit()
+}
+
+// Fatalw logs a message with the given key/value pairs at LevelFatal
+// level, then exits.
+func (l *Logger) Fatalw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelFatal) {
+		l.log(LevelFatal, msg, keysAndValues...)
+	}
+	l.exit()
+}
+
+// exit terminates the application, unless the Logger was derived with
+// WithoutExit.
+func (l *Logger) exit() {
+	if !l.noexit {
+		logging.Exit(1)
+	}
+}
+
+// log sends a record directly to the slog handler, so that the source
+// location is the caller of the logging method and not this adapter; the
+// handler decides whether to report it, e.g. with AddSource.
+func (l *Logger) log(level slog.Level, msg string, keysAndValues ...interface{}) {
+	ctx := context.Background()
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+	pc := l.pc
+	if pc == 0 {
+		// skip log and the logging method
+		pc = logging.CallerPC(2 + l.skip)
+	}
+	t := l.time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	record := slog.NewRecord(t, level, strings.TrimRight(msg, "\n\r"), pc)
+	if l.name != "" {
+		record.AddAttrs(slog.String("logger", l.name))
+	}
+	keysAndValues, stack := logging.SplitStackTrace(keysAndValues)
+	record.Add(logging.Resolve(keysAndValues)...)
+	if stack != nil {
+		record.AddAttrs(slog.String(logging.StackKey, stack.String()))
+	}
+	_ = l.logger.Handler().Handle(ctx, record)
+}
@@ -0,0 +1,163 @@
+package slog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dihedron/go-log-facade/logging"
+	"github.com/dihedron/go-log-facade/logging/stream"
+)
+
+func TestLevelMapping(t *testing.T) {
+	tests := []struct {
+		level logging.Level
+		slog  slog.Level
+	}{
+		{logging.LevelTrace, slog.Level(-8)},
+		{logging.LevelDebug, slog.LevelDebug},
+		{logging.LevelInfo, slog.LevelInfo},
+		{logging.LevelWarn, slog.LevelWarn},
+		{logging.LevelError, slog.LevelError},
+		{logging.LevelPanic, LevelPanic},
+		{logging.LevelFatal, LevelFatal},
+	}
+	for _, test := range tests {
+		if got := ToSlogLevel(test.level); got != test.slog {
+			t.Errorf("ToSlogLevel(%v) = %v, want %v", test.level, got, test.slog)
+		}
+		if got := FromSlogLevel(test.slog); got != test.level {
+			t.Errorf("FromSlogLevel(%v) = %v, want %v", test.slog, got, test.level)
+		}
+	}
+	// levels in between map to the facade level below them
+	for level, want := range map[slog.Level]logging.Level{
+		-12:                 logging.LevelTrace,
+		-5:                  logging.LevelTrace,
+		slog.LevelDebug + 1: logging.LevelDebug,
+		slog.LevelInfo + 2:  logging.LevelInfo,
+		slog.LevelError + 1: logging.LevelError,
+		LevelFatal + 4:      logging.LevelFatal,
+	} {
+		if got := FromSlogLevel(level); got != want {
+			t.Errorf("FromSlogLevel(%v) = %v, want %v", level, got, want)
+		}
+	}
+}
+
+// newHandler returns a Handler writing to a logfmt stream Logger at
+// LevelTrace, and the buffer the lines are written to.
+func newHandler(caller logging.Caller) (*Handler, *bytes.Buffer) {
+	buffer := &bytes.Buffer{}
+	logger := stream.NewLogger(buffer, stream.WithEncoder(&stream.LogfmtEncoder{}), stream.WithCaller(caller))
+	logger.SetLevel(logging.LevelTrace)
+	return NewHandler(logger), buffer
+}
+
+// fields returns the lines written to the buffer without the timestamp.
+func fields(buffer *bytes.Buffer) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		_, rest, _ := strings.Cut(line, " ")
+		lines = append(lines, rest)
+	}
+	return lines
+}
+
+func TestHandlerAttributes(t *testing.T) {
+	handler, buffer := newHandler(logging.CallerOff)
+	logger := slog.New(handler).
+		With("a", 1).
+		WithGroup("g").
+		With("b", 2).
+		WithGroup("h")
+
+	logger.Info("message",
+		"c", 3,
+		slog.Group("nested", "d", 4, slog.Group("deeper", "e", 5)),
+		slog.Group("", "inline", 6),
+		slog.Attr{},
+		slog.Group("empty"),
+	)
+
+	want := "level=info msg=message a=1 g.b=2 g.h.c=3 g.h.nested.d=4 g.h.nested.deeper.e=5 g.h.inline=6"
+	if got := fields(buffer); len(got) != 1 || got[0] != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHandlerLevels(t *testing.T) {
+	handler, buffer := newHandler(logging.CallerOff)
+	logger := slog.New(handler)
+	ctx := context.Background()
+
+	for _, level := range []slog.Level{LevelTrace, slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError, LevelPanic, LevelFatal} {
+		logger.Log(ctx, level, "message")
+	}
+
+	want := []string{"trace", "debug", "info", "warn", "error", "error", "error"}
+	got := fields(buffer)
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(got), len(want), buffer.String())
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], "level="+want[i]+" ") {
+			t.Errorf("line %d = %q, want level %s", i, got[i], want[i])
+		}
+	}
+
+	handler.logger.SetLevel(logging.LevelWarn)
+	if handler.Enabled(ctx, slog.LevelInfo) || !handler.Enabled(ctx, slog.LevelWarn) {
+		t.Errorf("Enabled does not follow the Logger's level")
+	}
+}
+
+func TestHandlerCaller(t *testing.T) {
+	handler, buffer := newHandler(logging.CallerShort)
+	_, _, line, _ := runtime.Caller(0)
+	slog.New(handler).Info("message")
+
+	want := "caller=slog/slog_test.go:" + strconv.Itoa(line+1)
+	if got := fields(buffer); len(got) != 1 || !strings.HasSuffix(got[0], want) {
+		t.Errorf("got %q, want suffix %q", got, want)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	backend := slog.New(slog.NewTextHandler(buffer, &slog.HandlerOptions{
+		Level: LevelTrace,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	}))
+	logger := NewLogger(backend)
+	logger.SetLevel(logging.LevelTrace)
+
+	child := logger.Named("parent").Named("child").With("key", "value")
+	child.Trace("trace")
+	child.Infow("message", "other", 1)
+	logger.Warnf("warning %d", 42)
+
+	want := []string{
+		`level=DEBUG-4 msg=trace key=value logger=parent.child`,
+		`level=INFO msg=message key=value logger=parent.child other=1`,
+		`level=WARN msg="warning 42"`,
+	}
+	got := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	logger.SetLevel(logging.LevelWarn)
+	if child.Enabled(logging.LevelInfo) || !child.Enabled(logging.LevelWarn) {
+		t.Errorf("the child does not share the level of its parent")
+	}
+}
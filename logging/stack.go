@@ -0,0 +1,158 @@
+package logging
+
+import (
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// StackKey is the key under which adapters report stack traces as a
+// dedicated field.
+const StackKey = "stack"
+
+// maxStackDepth is the maximum number of frames captured in a StackTrace.
+const maxStackDepth = 64
+
+// facade is the import path of this package; frames of functions in it
+// and in its sub-packages (adapters and wrappers) are left out of stack
+// traces.
+var facade = reflect.TypeOf(Field{}).PkgPath()
+
+// StackTrace is a cleaned-up stack trace, from the innermost frame
+// outwards, without runtime and logging facade frames.
+type StackTrace []runtime.Frame
+
+// CaptureStackTrace returns the stack trace of the calling goroutine,
+// starting from the innermost function outside of the logging facade.
+func CaptureStackTrace() StackTrace {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	stack := StackTrace{}
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") &&
+			!strings.HasPrefix(frame.Function, facade+".") &&
+			!strings.HasPrefix(frame.Function, facade+"/") {
+			stack = append(stack, frame)
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+// String renders the stack trace as in Go panics, i.e. with each frame on
+// two lines, the function and then its tab-indented location.
+func (s StackTrace) String() string {
+	var buffer strings.Builder
+	for i, frame := range s {
+		if i > 0 {
+			buffer.WriteString("\n")
+		}
+		buffer.WriteString(frame.Function)
+		buffer.WriteString("\n\t")
+		buffer.WriteString(frame.File)
+		buffer.WriteString(":")
+		buffer.WriteString(strconv.Itoa(frame.Line))
+	}
+	return buffer.String()
+}
+
+// StackLogger is implemented by the Loggers that add the stack trace of
+// the call to the entries at or above a given level, so that Loggers
+// writing entries later, or from another goroutine, can capture the stack
+// trace at the time of the call and pass it on.
+type StackLogger interface {
+	// StackLevel returns the level at or above which entries get the
+	// stack trace of the call.
+	StackLevel() Level
+	// WithStack returns a child Logger that reports the given stack trace,
+	// instead of capturing its own, for the entries at or above its stack
+	// level; it shares the logging level of its parent.
+	WithStack(stack StackTrace) Logger
+}
+
+type stackRequest uint8
+
+// String implements fmt.Stringer.
+func (stackRequest) String() string {
+	return StackKey
+}
+
+// Stack, passed among the key/value pairs of a structured logging method,
+// requests the stack trace of the call regardless of the level at which
+// the Logger captures stack traces, e.g.
+//
+//	logger.Warnw("slow query", "elapsed", elapsed, logging.Stack)
+//
+// It can be used on its own or as the value of a key, which is ignored.
+const Stack stackRequest = 0
+
+// SplitStackTrace removes Stack requests and StackTrace values, together
+// with their keys, from the key/value pairs; it returns the remaining pairs
+// and the stack trace, which is captured now if requested. If there is no
+// stack trace, the original slice is returned.
+func SplitStackTrace(keysAndValues []interface{}) ([]interface{}, StackTrace) {
+	found := false
+	for _, value := range keysAndValues {
+		if isStack(value) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return keysAndValues, nil
+	}
+	var stack StackTrace
+	rest := make([]interface{}, 0, len(keysAndValues))
+	for i := 0; i < len(keysAndValues); i++ {
+		value := keysAndValues[i]
+		if _, ok := value.(string); ok && i < len(keysAndValues)-1 {
+			// a key and its value, as paired by ToFields: the key is
+			// dropped if the value is a stack
+			i++
+			if !isStack(keysAndValues[i]) {
+				rest = append(rest, value, keysAndValues[i])
+				continue
+			}
+			value = keysAndValues[i]
+		}
+		switch v := value.(type) {
+		case stackRequest:
+			if stack == nil {
+				stack = CaptureStackTrace()
+			}
+		case StackTrace:
+			stack = v
+		default:
+			rest = append(rest, value)
+		}
+	}
+	return rest, stack
+}
+
+// captureStackTrace replaces Stack requests among the key/value pairs
+// with the stack trace, so that it is the one of the call even if the
+// entry is written later or by another goroutine.
+func captureStackTrace(keysAndValues []interface{}) []interface{} {
+	for i, value := range keysAndValues {
+		if _, ok := value.(stackRequest); ok {
+			captured := make([]interface{}, len(keysAndValues))
+			copy(captured, keysAndValues)
+			captured[i] = CaptureStackTrace()
+			return captured
+		}
+	}
+	return keysAndValues
+}
+
+func isStack(value interface{}) bool {
+	switch value.(type) {
+	case stackRequest, StackTrace:
+		return true
+	}
+	return false
+}
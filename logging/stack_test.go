@@ -0,0 +1,62 @@
+package logging_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/dihedron/go-log-facade/logging"
+	"github.com/dihedron/go-log-facade/logging/async"
+	"github.com/dihedron/go-log-facade/logging/limit"
+	"github.com/dihedron/go-log-facade/logging/stream"
+	"github.com/dihedron/go-log-facade/logging/tee"
+)
+
+func failing(logger logging.Logger) {
+	logger.Error("level-triggered")
+	logger.Warnw("on demand", "key", "value", logging.Stack)
+}
+
+// TestStackTraces checks that the stack traces written through wrappers
+// are the ones of the calls, even when entries are written later or by
+// another goroutine, and that they are never altered.
+func TestStackTraces(t *testing.T) {
+	all := map[string]func(logging.Logger) logging.Logger{
+		"tee": func(logger logging.Logger) logging.Logger {
+			return tee.NewLogger(tee.Sink{Logger: logger, Level: logging.LevelTrace})
+		},
+		"async/limit": func(logger logging.Logger) logging.Logger {
+			return async.NewLogger(limit.NewLogger(logger, limit.WithMaxArgSize(5)))
+		},
+	}
+	for name, wrap := range wrappers {
+		all[name] = wrap
+	}
+	for name, wrap := range all {
+		t.Run(name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			inner := stream.NewLogger(buffer, stream.WithEncoder(&stream.LogfmtEncoder{}), stream.WithStackTrace(logging.LevelError))
+			inner.SetLevel(logging.LevelTrace)
+			logger := wrap(inner)
+
+			failing(logger.With("child", true))
+			if closer, ok := logger.(io.Closer); ok {
+				_ = closer.Close()
+			}
+
+			lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("got %d lines, want 2:\n%s", len(lines), buffer.String())
+			}
+			for _, line := range lines {
+				if !strings.Contains(line, ` stack="github.com/dihedron/go-log-facade/logging_test.failing\n`) {
+					t.Errorf("the stack trace is not the one of the call: %s", line)
+				}
+				if _, stack, _ := strings.Cut(line, " stack="); strings.Contains(line, logging.BadKey) || strings.Contains(stack, "truncated") {
+					t.Errorf("the stack trace was altered: %s", line)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package stream
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/dihedron/go-log-facade/logging"
+	"github.com/fatih/color"
+)
+
+// Entry is a single log entry, as passed to an Encoder.
+type Entry struct {
+	// Time is the time at which the entry was logged.
+	Time time.Time
+	// Level is the level of the entry.
+	Level logging.Level
+	// Name is the name of the Logger, or empty.
+	Name string
+	// Message is the formatted message.
+	Message string
+	// Fields holds the key/value pairs bound to the Logger and those
+	// passed to the logging method, in this order.
+	Fields []logging.Field
+	// Caller is the frame of the function that called the logging
+	// method, with only the parts configured with WithCaller; its fields
+	// are empty if not available.
+	Caller runtime.Frame
+	// Stack is the stack trace of the call, or nil.
+	Stack logging.StackTrace
+}
+
+// Tag returns the three-letter tag of the entry level, e.g. "INF".
+func (e *Entry) Tag() string {
+	if e.Level < logging.LevelOff {
+		return tags[e.Level]
+	}
+	return "???"
+}
+
+// Location returns the caller as "file:line function", leaving out the
+// parts that are not reported, or an empty string.
+func (e *Entry) Location() string {
+	return logging.FormatFrame(e.Caller)
+}
+
+// Encoder formats entries into a buffer; each call to Encode must write
+// a complete entry, including the trailing newline if needed.
+type Encoder interface {
+	Encode(buffer *bytes.Buffer, entry *Entry) error
+}
+
+var tags = [...]string{
+	logging.LevelTrace: "TRC",
+	logging.LevelDebug: "DBG",
+	logging.LevelInfo:  "INF",
+	logging.LevelWarn:  "WRN",
+	logging.LevelError: "ERR",
+	logging.LevelPanic: "PNC",
+	logging.LevelFatal: "FTL",
+}
+
+var colours = [...]func(format string, a ...interface{}) string{
+	logging.LevelTrace: color.HiWhiteString,
+	logging.LevelDebug: color.HiBlueString,
+	logging.LevelInfo:  color.HiGreenString,
+	logging.LevelWarn:  color.HiYellowString,
+	logging.LevelError: color.HiRedString,
+	logging.LevelPanic: color.HiMagentaString,
+	logging.LevelFatal: color.RedString,
+}
+
+// TextEncoder formats entries as human-readable lines, e.g.
+//
+//	2006-01-02T15:04:05.999-0700 [INF] name: message key=value (file.go:42)
+//
+// followed by the stack trace, if any, on tab-indented lines.
+type TextEncoder struct {
+	// TimeFormat is the layout of the timestamp; if empty, TimeFormat is used.
+	TimeFormat string
+	// Colour enables ANSI colouring of the level tag.
+	Colour bool
+	// Raw disables the escaping of line breaks and control characters in
+	// names and messages (see logging.Escape), which otherwise prevents
+	// untrusted input from forging entries; values are always quoted.
+	Raw bool
+}
+
+// Encode implements Encoder.
+func (e *TextEncoder) Encode(buffer *bytes.Buffer, entry *Entry) error {
+	layout := e.TimeFormat
+	if layout == "" {
+		layout = TimeFormat
+	}
+	buffer.WriteString(entry.Time.Format(layout))
+	buffer.WriteString(" [")
+	if e.Colour && entry.Level < logging.LevelOff {
+		buffer.WriteString(colours[entry.Level](entry.Tag()))
+	} else {
+		buffer.WriteString(entry.Tag())
+	}
+	buffer.WriteString("] ")
+	name, message := entry.Name, entry.Message
+	if !e.Raw {
+		name, message = logging.Escape(name), logging.Escape(message)
+	}
+	if name != "" {
+		buffer.WriteString(name)
+		buffer.WriteString(": ")
+	}
+	buffer.WriteString(message)
+	for _, field := range entry.Fields {
+		buffer.WriteString(" ")
+		buffer.WriteString(logging.Escape(field.Key))
+		buffer.WriteString("=")
+		buffer.WriteString(logging.FormatValue(field.Value))
+	}
+	if location := entry.Location(); location != "" {
+		buffer.WriteString(" (")
+		buffer.WriteString(location)
+		buffer.WriteString(")")
+	}
+	buffer.WriteString("\n")
+	for _, line := range strings.Split(entry.Stack.String(), "\n") {
+		if line != "" {
+			buffer.WriteString("\t")
+			buffer.WriteString(line)
+			buffer.WriteString("\n")
+		}
+	}
+	return nil
+}
+
+// JSONEncoder formats entries as line-delimited JSON objects; key/value
+// pairs are added as top-level members. Empty key names omit the
+// corresponding member.
+type JSONEncoder struct {
+	TimeKey    string
+	LevelKey   string
+	NameKey    string
+	MessageKey string
+	CallerKey  string
+	StackKey   string
+	// TimeFormat is the layout of the timestamp.
+	TimeFormat string
+}
+
+// NewJSONEncoder returns a JSONEncoder with the default key names ("time",
+// "level", "logger", "msg", "caller" and "stack") and RFC 3339 timestamps.
+func NewJSONEncoder() *JSONEncoder {
+	return &JSONEncoder{
+		TimeKey:    "time",
+		LevelKey:   "level",
+		NameKey:    "logger",
+		MessageKey: "msg",
+		CallerKey:  "caller",
+		StackKey:   logging.StackKey,
+		TimeFormat: time.RFC3339Nano,
+	}
+}
+
+// Encode implements Encoder.
+func (e *JSONEncoder) Encode(buffer *bytes.Buffer, entry *Entry) error {
+	buffer.WriteString("{")
+	first := true
+	member := func(key string, value interface{}) {
+		if key == "" {
+			return
+		}
+		if !first {
+			buffer.WriteString(",")
+		}
+		first = false
+		data, _ := json.Marshal(key)
+		buffer.Write(data)
+		buffer.WriteString(":")
+		buffer.Write(toJSON(value))
+	}
+	member(e.TimeKey, entry.Time.Format(e.TimeFormat))
+	member(e.LevelKey, entry.Level.String())
+	if entry.Name != "" {
+		member(e.NameKey, entry.Name)
+	}
+	member(e.MessageKey, entry.Message)
+	for _, field := range entry.Fields {
+		member(field.Key, field.Value)
+	}
+	if location := entry.Location(); location != "" {
+		member(e.CallerKey, location)
+	}
+	if len(entry.Stack) > 0 {
+		member(e.StackKey, entry.Stack.String())
+	}
+	buffer.WriteString("}\n")
+	return nil
+}
+
+// toJSON marshals the value, falling back to its string representation
+// for errors and for values that cannot be marshalled.
+func toJSON(value interface{}) []byte {
+	if err, ok := value.(error); ok {
+		if _, ok := value.(json.Marshaler); !ok {
+			value = err.Error()
+		}
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprintf("%v", value))
+	}
+	return data
+}
+
+// LogfmtEncoder formats entries as logfmt lines, e.g.
+//
+//	time=2006-01-02T15:04:05Z level=info logger=name msg="a message" key=value caller=file.go:42
+type LogfmtEncoder struct {
+	// TimeFormat is the layout of the timestamp; if empty, RFC 3339 is used.
+	TimeFormat string
+}
+
+// Encode implements Encoder.
+func (e *LogfmtEncoder) Encode(buffer *bytes.Buffer, entry *Entry) error {
+	layout := e.TimeFormat
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+	keysAndValues := []interface{}{"time", entry.Time.Format(layout), "level", entry.Level.String()}
+	if entry.Name != "" {
+		keysAndValues = append(keysAndValues, "logger", entry.Name)
+	}
+	keysAndValues = append(keysAndValues, "msg", entry.Message)
+	for _, field := range entry.Fields {
+		keysAndValues = append(keysAndValues, field.Key, field.Value)
+	}
+	if location := entry.Location(); location != "" {
+		keysAndValues = append(keysAndValues, "caller", location)
+	}
+	if len(entry.Stack) > 0 {
+		keysAndValues = append(keysAndValues, logging.StackKey, entry.Stack.String())
+	}
+	buffer.WriteString(logging.FormatFields(keysAndValues...))
+	buffer.WriteString("\n")
+	return nil
+}
+
+// TemplateEncoder formats entries by executing a text/template with the
+// *Entry as data; besides the Entry's fields and methods, templates can
+// use the "fields" function, rendering a list of Fields as key=value
+// pairs, and the "json" function. A newline is added if the template
+// output does not end with one.
+type TemplateEncoder struct {
+	template *template.Template
+	// Raw disables the escaping of line breaks and control characters in
+	// the entry's Name, Message and string field values (see logging.Escape),
+	// which otherwise prevents untrusted input from forging entries.
+	Raw bool
+}
+
+// NewTemplateEncoder parses the given template text, e.g.
+//
+//	{{.Time.Format "15:04:05"}} {{.Tag}} {{.Message}} {{fields .Fields}}
+func NewTemplateEncoder(text string) (*TemplateEncoder, error) {
+	t, err := template.New("entry").Funcs(template.FuncMap{
+		"fields": func(fields []logging.Field) string {
+			keysAndValues := make([]interface{}, 0, 2*len(fields))
+			for _, field := range fields {
+				keysAndValues = append(keysAndValues, field.Key, field.Value)
+			}
+			return logging.FormatFields(keysAndValues...)
+		},
+		"json": func(value interface{}) string {
+			return string(toJSON(value))
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing log template: %w", err)
+	}
+	return &TemplateEncoder{template: t}, nil
+}
+
+// Encode implements Encoder.
+func (e *TemplateEncoder) Encode(buffer *bytes.Buffer, entry *Entry) error {
+	if !e.Raw {
+		escaped := *entry
+		escaped.Name = logging.Escape(entry.Name)
+		escaped.Message = logging.Escape(entry.Message)
+		escaped.Fields = make([]logging.Field, len(entry.Fields))
+		for i, field := range entry.Fields {
+			escaped.Fields[i] = logging.Field{
+				Key:   logging.Escape(field.Key),
+				Value: logging.EscapeFields(field.Value)[0],
+			}
+		}
+		entry = &escaped
+	}
+	if err := e.template.Execute(buffer, entry); err != nil {
+		return fmt.Errorf("error executing log template: %w", err)
+	}
+	if buffer.Len() == 0 || buffer.Bytes()[buffer.Len()-1] != '\n' {
+		buffer.WriteString("\n")
+	}
+	return nil
+}
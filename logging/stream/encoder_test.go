@@ -0,0 +1,158 @@
+package stream
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dihedron/go-log-facade/logging"
+)
+
+// entry returns a sample Entry with a fixed time and no caller.
+func entry() *Entry {
+	return &Entry{
+		Time:    time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+		Level:   logging.LevelWarn,
+		Name:    "name",
+		Message: "a message",
+		Fields: []logging.Field{
+			{Key: "key", Value: "value"},
+			{Key: "number", Value: 42},
+		},
+	}
+}
+
+func encode(t *testing.T, encoder Encoder, entry *Entry) string {
+	t.Helper()
+	buffer := &bytes.Buffer{}
+	if err := encoder.Encode(buffer, entry); err != nil {
+		t.Fatalf("Encode() returned %v", err)
+	}
+	return buffer.String()
+}
+
+func TestJSONEncoder(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoder *JSONEncoder
+		want    string
+	}{
+		{
+			"default keys",
+			NewJSONEncoder(),
+			`{"time":"2006-01-02T15:04:05Z","level":"warn","logger":"name","msg":"a message","key":"value","number":42}` + "\n",
+		},
+		{
+			"custom keys",
+			&JSONEncoder{TimeKey: "ts", LevelKey: "severity", NameKey: "component", MessageKey: "message", TimeFormat: time.Kitchen},
+			`{"ts":"3:04PM","severity":"warn","component":"name","message":"a message","key":"value","number":42}` + "\n",
+		},
+		{
+			"empty keys",
+			&JSONEncoder{MessageKey: "msg"},
+			`{"msg":"a message","key":"value","number":42}` + "\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := encode(t, test.encoder, entry()); got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestJSONEncoderValues(t *testing.T) {
+	e := entry()
+	e.Name = ""
+	e.Message = "line\nbreak"
+	e.Fields = []logging.Field{
+		{Key: "error", Value: errors.New("failed")},
+		{Key: "channel", Value: make(chan int)},
+	}
+	want := `{"time":"2006-01-02T15:04:05Z","level":"warn","msg":"line\nbreak","error":"failed","channel":"`
+	if got := encode(t, NewJSONEncoder(), e); len(got) < len(want) || got[:len(want)] != want {
+		t.Errorf("got %s, want prefix %s", got, want)
+	}
+}
+
+func TestLogfmtEncoder(t *testing.T) {
+	e := entry()
+	e.Fields = append(e.Fields,
+		logging.Field{Key: "empty", Value: ""},
+		logging.Field{Key: "quote", Value: `say "hi"`},
+		logging.Field{Key: "equals", Value: "a=b"},
+		logging.Field{Key: "control", Value: "line\nbreak"},
+	)
+	want := `time=2006-01-02T15:04:05Z level=warn logger=name msg="a message" key=value number=42` +
+		` empty="" quote="say \"hi\"" equals="a=b" control="line\nbreak"` + "\n"
+	if got := encode(t, &LogfmtEncoder{}, e); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	if got, want := encode(t, &LogfmtEncoder{TimeFormat: time.Kitchen}, entry())[:12], "time=3:04PM "; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestTemplateEncoder(t *testing.T) {
+	tests := []struct {
+		name, template, want string
+	}{
+		{
+			"fields",
+			`{{.Time.Format "15:04:05"}} {{.Tag}} {{.Name}}: {{.Message}} {{fields .Fields}}`,
+			"15:04:05 WRN name: a message key=value number=42\n",
+		},
+		{
+			"json",
+			`{{json .Message}} {{json .Fields}}`,
+			`"a message" [{"Key":"key","Value":"value"},{"Key":"number","Value":42}]` + "\n",
+		},
+		{
+			"trailing newline kept",
+			"{{.Message}}\n",
+			"a message\n",
+		},
+		{
+			"empty output",
+			"",
+			"\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encoder, err := NewTemplateEncoder(test.template)
+			if err != nil {
+				t.Fatalf("NewTemplateEncoder() returned %v", err)
+			}
+			if got := encode(t, encoder, entry()); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+
+	if _, err := NewTemplateEncoder("{{.Message"); err == nil {
+		t.Errorf("NewTemplateEncoder() accepted an invalid template")
+	}
+	encoder, _ := NewTemplateEncoder("{{.Missing}}")
+	if err := encoder.Encode(&bytes.Buffer{}, entry()); err == nil {
+		t.Errorf("Encode() did not report the template error")
+	}
+}
+
+func TestTemplateEncoderEscaping(t *testing.T) {
+	e := entry()
+	e.Message = "line\nbreak"
+	e.Fields = []logging.Field{{Key: "key", Value: "value\nforged"}}
+
+	encoder, _ := NewTemplateEncoder("{{.Message}} {{fields .Fields}}")
+	if got, want := encode(t, encoder, e), `line\nbreak key=value\nforged`+"\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	encoder.Raw = true
+	if got, want := encode(t, encoder, e), "line\nbreak key=\"value\\nforged\"\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
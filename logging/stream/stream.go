@@ -2,51 +2,203 @@ package stream
 
 import (
 	"bytes"
-	"fmt"
 	"io"
-	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dihedron/go-log-facade/logging"
-	"github.com/fatih/color"
 	"github.com/mattn/go-isatty"
 )
 
 const TimeFormat = "2006-01-02T15:04:05.999-0700"
 
-// Logger is a logger that write sits messages to a stream.
+// maxPooledBuffer is the capacity above which line buffers are not
+// returned to the pool, so that a few huge messages do not pin memory.
+const maxPooledBuffer = 64 * 1024
+
+var buffers = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// Logger is a logger that write sits messages to a stream; each message
+// is formatted into a buffer and written with a single call, under a
+// lock shared with all the Logger's children, so that lines written by
+// concurrent goroutines never interleave.
 type Logger struct {
-	stream io.Writer
-	level  *logging.Level
+	stream  io.Writer
+	encoder Encoder
+	lock    *sync.Mutex
+	level   *logging.LevelVar
+	name    string
+	fields  []interface{}
+	stack   logging.Level
+	caller  logging.Caller
+	skip    int
+	pc      uintptr
+	trace   logging.StackTrace
+	time    time.Time
+	noexit  bool
+}
+
+// Option is a functional option for configuring a stream Logger.
+type Option func(*Logger)
+
+// WithEncoder sets the Encoder used to format entries; by default, a
+// TextEncoder is used, with coloured levels if the stream is a terminal.
+func WithEncoder(encoder Encoder) Option {
+	return func(l *Logger) {
+		l.encoder = encoder
+	}
+}
+
+// WithCaller sets how the location of the logging calls is reported; the
+// default is logging.CallerFull.
+func WithCaller(caller logging.Caller) Option {
+	return func(l *Logger) {
+		l.caller = caller
+	}
+}
+
+// WithStackTrace adds the stack trace of the call to entries at or above
+// the given level; by default, stack traces are only added on demand,
+// with logging.Stack.
+func WithStackTrace(level logging.Level) Option {
+	return func(l *Logger) {
+		l.stack = level
+	}
 }
 
-// NewLogger returns an instance of a stream Logger.
-func NewLogger(stream *os.File) *Logger {
-	return &Logger{
+// NewLogger returns an instance of a stream Logger writing to the given
+// io.Writer and configured with the given options. Writers implementing
+// io.Closer and Sync() error are closed and flushed by the Logger's Close
+// and Sync methods.
+func NewLogger(stream io.Writer, options ...Option) *Logger {
+	l := &Logger{
 		stream: stream,
+		lock:   &sync.Mutex{},
+		level:  logging.NewLevelVar(),
+		stack:  logging.LevelOff,
+		caller: logging.CallerFull,
+	}
+	for _, option := range options {
+		option(l)
+	}
+	if l.encoder == nil {
+		l.encoder = &TextEncoder{
+			TimeFormat: TimeFormat,
+			Colour:     isTerminal(stream),
+		}
 	}
+	return l
 }
 
 func (l *Logger) SetLevel(level logging.Level) {
-	l.level = &level
+	l.level.Set(level)
 }
 
 func (l *Logger) GetLevel() *logging.Level {
-	if l.level != nil {
-		// there's a specific logging level for this logger
-		return l.level
-	}
-	// there is no per-instance logging level, return the global level
-	level := logging.GetGlobalLevel()
-	return &level
+	// return the per-instance logging level if set, the global level otherwise
+	return l.level.Pointer()
 }
 
 func (l *Logger) ResetLevel() {
-	l.level = nil
+	l.level.Reset()
+}
+
+// Enabled returns whether messages at the given level would be written.
+func (l *Logger) Enabled(level logging.Level) bool {
+	return l.level.Enabled(level)
+}
+
+// With returns a child Logger writing to the same stream, which adds
+// the given key/value pairs to every message; the child shares the
+// logging level of its parent.
+func (l *Logger) With(keysAndValues ...interface{}) logging.Logger {
+	child := *l
+	child.fields = append(l.fields[:len(l.fields):len(l.fields)], keysAndValues...)
+	return &child
+}
+
+// Named returns a child Logger writing to the same stream, whose name
+// is the given name appended to the parent's name.
+func (l *Logger) Named(name string) logging.Logger {
+	child := *l
+	if l.name != "" {
+		child.name = l.name + "." + name
+	} else {
+		child.name = name
+	}
+	return &child
 }
 
+// AddCallerSkip returns a child Logger writing to the same stream, which
+// reports as caller the function skip frames further up the stack; it
+// shares the logging level of its parent.
+func (l *Logger) AddCallerSkip(skip int) logging.Logger {
+	child := *l
+	child.skip += skip
+	return &child
+}
+
+// WithCallerPC returns a child Logger writing to the same stream, which
+// reports the given program counter as the caller of all its logging
+// calls; it shares the logging level of its parent.
+func (l *Logger) WithCallerPC(pc uintptr) logging.Logger {
+	child := *l
+	child.pc = pc
+	return &child
+}
+
+// WithoutExit returns a child Logger whose Fatal methods log the message
+// and return instead of exiting; it shares the logging level of its
+// parent.
+func (l *Logger) WithoutExit() logging.Logger {
+	child := *l
+	child.noexit = true
+	return &child
+}
+
+// StackLevel returns the level at or above which entries get the stack
+// trace of the call.
+func (l *Logger) StackLevel() logging.Level {
+	return l.stack
+}
+
+// WithStack returns a child Logger that reports the given stack trace,
+// instead of capturing its own, for the entries at or above its stack
+// level; it shares the logging level of its parent.
+func (l *Logger) WithStack(stack logging.StackTrace) logging.Logger {
+	child := *l
+	child.trace = stack
+	return &child
+}
+
+// WithTime returns a child Logger writing to the same stream, which reports
+// the given time as the time of all its entries; it shares the logging
+// level of its parent.
+func (l *Logger) WithTime(t time.Time) logging.Logger {
+	child := *l
+	child.time = t
+	return &child
+}
+
+// Sync flushes the underlying stream, if it supports it.
+func (l *Logger) Sync() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if syncer, ok := l.stream.(interface{ Sync() error }); ok {
+		return syncer.Sync()
+	}
+	return nil
+}
+
+// Close closes the underlying stream, if it supports it.
 func (l *Logger) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
 	if closer, ok := l.stream.(io.Closer); ok {
 		return closer.Close()
 	}
@@ -55,146 +207,220 @@ func (l *Logger) Close() error {
 
 // Trace logs a message at LevelTrace level.
 func (l *Logger) Trace(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelTrace {
-		frame := logging.GetCallerFrame(3)
-		info := fmt.Sprintf("(%s:%d)", frame.File, frame.Line)
-		if file, ok := l.stream.(*os.File); ok && isatty.IsTerminal(file.Fd()) {
-			l.write(color.HiWhiteString("TRC"), append(args, info)...)
-		} else {
-			l.write("TRC", append(args, info)...)
-		}
+	if l.level.Enabled(logging.LevelTrace) {
+		l.log(logging.LevelTrace, logging.Sprint(args...))
 	}
 }
 
 // Tracef logs a message at LevelTrace level.
 func (l *Logger) Tracef(msg string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelTrace {
-		frame := logging.GetCallerFrame(3)
-		info := fmt.Sprintf("(%s:%d)", frame.File, frame.Line)
-		if file, ok := l.stream.(*os.File); ok && isatty.IsTerminal(file.Fd()) {
-			l.writef(color.HiWhiteString("TRC"), msg+" "+info, args...)
-		} else {
-			l.writef("TRC", msg+" "+info, args...)
-		}
+	if l.level.Enabled(logging.LevelTrace) {
+		l.log(logging.LevelTrace, logging.Sprintf(strings.TrimSpace(msg), args...))
+	}
+}
+
+// Tracew logs a message with the given key/value pairs at LevelTrace level.
+func (l *Logger) Tracew(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelTrace) {
+		l.log(logging.LevelTrace, strings.TrimSpace(msg), keysAndValues...)
 	}
 }
 
 // Debug logs a message at LevelDebug level.
 func (l *Logger) Debug(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelDebug {
-		frame := logging.GetCallerFrame(3)
-		info := fmt.Sprintf("(%s:%d)", frame.File, frame.Line)
-		if file, ok := l.stream.(*os.File); ok && isatty.IsTerminal(file.Fd()) {
-			l.write(color.HiBlueString("DBG"), append(args, info)...)
-		} else {
-			l.write("DBG", append(args, info)...)
-		}
+	if l.level.Enabled(logging.LevelDebug) {
+		l.log(logging.LevelDebug, logging.Sprint(args...))
 	}
 }
 
 // Debugf logs a message at LevelDebug level.
 func (l *Logger) Debugf(msg string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelDebug {
-		frame := logging.GetCallerFrame(3)
-		info := fmt.Sprintf("(%s:%d)", frame.File, frame.Line)
-		if file, ok := l.stream.(*os.File); ok && isatty.IsTerminal(file.Fd()) {
-			l.writef(color.HiBlueString("DBG"), msg+" "+info, args...)
-		} else {
-			l.writef("DBG", msg+" "+info, args...)
-		}
+	if l.level.Enabled(logging.LevelDebug) {
+		l.log(logging.LevelDebug, logging.Sprintf(strings.TrimSpace(msg), args...))
+	}
+}
+
+// Debugw logs a message with the given key/value pairs at LevelDebug level.
+func (l *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelDebug) {
+		l.log(logging.LevelDebug, strings.TrimSpace(msg), keysAndValues...)
 	}
 }
 
 // Info logs a message at LevelInfo level.
 func (l *Logger) Info(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelInfo {
-		frame := logging.GetCallerFrame(3)
-		info := fmt.Sprintf("(%s:%d)", frame.File, frame.Line)
-		if file, ok := l.stream.(*os.File); ok && isatty.IsTerminal(file.Fd()) {
-			l.write(color.HiGreenString("INF"), append(args, info)...)
-		} else {
-			l.write("INF", append(args, info)...)
-		}
+	if l.level.Enabled(logging.LevelInfo) {
+		l.log(logging.LevelInfo, logging.Sprint(args...))
 	}
 }
 
-// Infof logs a message at LevelInfof level.
+// Infof logs a message at LevelInfo level.
 func (l *Logger) Infof(msg string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelInfo {
-		frame := logging.GetCallerFrame(3)
-		info := fmt.Sprintf("(%s:%d)", frame.File, frame.Line)
-		if file, ok := l.stream.(*os.File); ok && isatty.IsTerminal(file.Fd()) {
-			l.writef(color.HiGreenString("INF"), msg+" "+info, args...)
-		} else {
-			l.writef("INF", msg+" "+info, args...)
-		}
+	if l.level.Enabled(logging.LevelInfo) {
+		l.log(logging.LevelInfo, logging.Sprintf(strings.TrimSpace(msg), args...))
+	}
+}
+
+// Infow logs a message with the given key/value pairs at LevelInfo level.
+func (l *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelInfo) {
+		l.log(logging.LevelInfo, strings.TrimSpace(msg), keysAndValues...)
 	}
 }
 
 // Warn logs a message at LevelWarn level.
 func (l *Logger) Warn(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelWarn {
-		frame := logging.GetCallerFrame(3)
-		info := fmt.Sprintf("(%s:%d)", frame.File, frame.Line)
-		if file, ok := l.stream.(*os.File); ok && isatty.IsTerminal(file.Fd()) {
-			l.write(color.HiYellowString("WRN"), append(args, info)...)
-		} else {
-			l.write("WRN", append(args, info)...)
-		}
+	if l.level.Enabled(logging.LevelWarn) {
+		l.log(logging.LevelWarn, logging.Sprint(args...))
 	}
 }
 
 // Warnf logs a message at LevelWarn level.
 func (l *Logger) Warnf(msg string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelWarn {
-		frame := logging.GetCallerFrame(3)
-		info := fmt.Sprintf("(%s:%d)", frame.File, frame.Line)
-		if file, ok := l.stream.(*os.File); ok && isatty.IsTerminal(file.Fd()) {
-			l.writef(color.HiYellowString("WRN"), msg+" "+info, args...)
-		} else {
-			l.writef("WRN", msg+" "+info, args...)
-		}
+	if l.level.Enabled(logging.LevelWarn) {
+		l.log(logging.LevelWarn, logging.Sprintf(strings.TrimSpace(msg), args...))
+	}
+}
+
+// Warnw logs a message with the given key/value pairs at LevelWarn level.
+func (l *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelWarn) {
+		l.log(logging.LevelWarn, strings.TrimSpace(msg), keysAndValues...)
 	}
 }
 
 // Error logs a message at LevelError level.
 func (l *Logger) Error(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelError {
-		frame := logging.GetCallerFrame(3)
-		info := fmt.Sprintf("(%s:%d)", frame.File, frame.Line)
-		if file, ok := l.stream.(*os.File); ok && isatty.IsTerminal(file.Fd()) {
-			l.write(color.HiRedString("ERR"), append(args, info)...)
-		} else {
-			l.write("ERR", append(args, info)...)
-		}
+	if l.level.Enabled(logging.LevelError) {
+		l.log(logging.LevelError, logging.Sprint(args...))
 	}
 }
 
 // Errorf logs a message at LevelError level.
 func (l *Logger) Errorf(msg string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelError {
-		frame := logging.GetCallerFrame(3)
-		info := fmt.Sprintf("(%s:%d)", frame.File, frame.Line)
-		if file, ok := l.stream.(*os.File); ok && isatty.IsTerminal(file.Fd()) {
-			l.writef(color.HiRedString("ERR"), msg+" "+info, args...)
-		} else {
-			l.writef("ERR", msg+" "+info, args...)
-		}
+	if l.level.Enabled(logging.LevelError) {
+		l.log(logging.LevelError, logging.Sprintf(strings.TrimSpace(msg), args...))
 	}
 }
 
-func (l *Logger) write(level string, args ...interface{}) {
-	var buffer bytes.Buffer
-	for argNum, arg := range args {
-		if argNum > 0 {
-			buffer.WriteString(" ")
-		}
-		buffer.WriteString(fmt.Sprintf("%v", arg))
+// Errorw logs a message with the given key/value pairs at LevelError level.
+func (l *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelError) {
+		l.log(logging.LevelError, strings.TrimSpace(msg), keysAndValues...)
+	}
+}
+
+// Panic logs a message at LevelPanic level, then panics.
+func (l *Logger) Panic(args ...interface{}) {
+	if l.level.Enabled(logging.LevelPanic) {
+		l.log(logging.LevelPanic, logging.Sprint(args...))
+	}
+	panic(logging.Sprint(args...))
+}
+
+// Panicf logs a message at LevelPanic level, then panics.
+func (l *Logger) Panicf(msg string, args ...interface{}) {
+	if l.level.Enabled(logging.LevelPanic) {
+		l.log(logging.LevelPanic, logging.Sprintf(strings.TrimSpace(msg), args...))
+	}
+	panic(logging.Sprintf(msg, args...))
+}
+
+// Panicw logs a message with the given key/value pairs at LevelPanic
+// level, then panics.
+func (l *Logger) Panicw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelPanic) {
+		l.log(logging.LevelPanic, strings.TrimSpace(msg), keysAndValues...)
 	}
-	fmt.Fprintf(l.stream, "%s [%s] %s\n", time.Now().Format(TimeFormat), level, buffer.String())
+	panic(msg)
 }
 
-func (l *Logger) writef(level string, msg string, args ...interface{}) {
-	message := fmt.Sprintf(strings.TrimSpace(msg), args...)
-	fmt.Fprintf(l.stream, "%s [%s] %s\n", time.Now().Format(TimeFormat), level, message)
+// Fatal logs a message at LevelFatal level, flushes the stream and exits.
+func (l *Logger) Fatal(args ...interface{}) {
+	if l.level.Enabled(logging.LevelFatal) {
+		l.log(logging.LevelFatal, logging.Sprint(args...))
+	}
+	l.sync()
+	l.exit()
+}
+
+// Fatalf logs a message at LevelFatal level, flushes the stream and exits.
+func (l *Logger) Fatalf(msg string, args ...interface{}) {
+	if l.level.Enabled(logging.LevelFatal) {
+		l.log(logging.LevelFatal, logging.Sprintf(strings.TrimSpace(msg), args...))
+	}
+	l.sync()
+	l.exit()
+}
+
+// Fatalw logs a message with the given key/value pairs at LevelFatal
+// level, flushes the stream and exits.
+func (l *Logger) Fatalw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelFatal) {
+		l.log(logging.LevelFatal, strings.TrimSpace(msg), keysAndValues...)
+	}
+	l.sync()
+	l.exit()
+}
+
+// exit terminates the application, unless the Logger was derived with
+// WithoutExit.
+func (l *Logger) exit() {
+	if !l.noexit {
+		logging.Exit(1)
+	}
+}
+
+// sync flushes the underlying stream, ignoring errors.
+func (l *Logger) sync() {
+	_ = l.Sync()
+}
+
+// isTerminal returns whether the writer is a file descriptor attached
+// to a terminal.
+func isTerminal(stream io.Writer) bool {
+	if file, ok := stream.(interface{ Fd() uintptr }); ok {
+		return isatty.IsTerminal(file.Fd()) || isatty.IsCygwinTerminal(file.Fd())
+	}
+	return false
+}
+
+// log encodes the entry into a pooled buffer and writes it to the stream
+// with a single call.
+func (l *Logger) log(level logging.Level, message string, keysAndValues ...interface{}) {
+	keysAndValues, stack := logging.SplitStackTrace(keysAndValues)
+	if stack == nil && level >= l.stack {
+		stack = l.trace
+		if stack == nil {
+			stack = logging.CaptureStackTrace()
+		}
+	}
+	pc := l.pc
+	if pc == 0 && l.caller != logging.CallerOff {
+		// skip log and the logging method
+		pc = logging.CallerPC(2 + l.skip)
+	}
+	t := l.time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	entry := &Entry{
+		Time:    t,
+		Level:   level,
+		Name:    l.name,
+		Message: message,
+		Fields:  logging.ToFields(logging.Resolve(append(l.fields[:len(l.fields):len(l.fields)], keysAndValues...))...),
+		Caller:  l.caller.Frame(pc),
+		Stack:   stack,
+	}
+	buffer := buffers.Get().(*bytes.Buffer)
+	buffer.Reset()
+	if err := l.encoder.Encode(buffer, entry); err == nil {
+		l.lock.Lock()
+		_, _ = l.stream.Write(buffer.Bytes())
+		l.lock.Unlock()
+	}
+	if buffer.Cap() <= maxPooledBuffer {
+		buffers.Put(buffer)
+	}
 }
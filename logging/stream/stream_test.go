@@ -0,0 +1,97 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dihedron/go-log-facade/logging"
+)
+
+// chunkedWriter is a non-atomic writer: it copies each write a few bytes
+// at a time, yielding in between, so that concurrent writes would
+// interleave; it counts the writes that overlap.
+type chunkedWriter struct {
+	lock     sync.Mutex
+	buffer   bytes.Buffer
+	writing  int32
+	overlaps int32
+}
+
+func (w *chunkedWriter) Write(data []byte) (int, error) {
+	if atomic.AddInt32(&w.writing, 1) > 1 {
+		atomic.AddInt32(&w.overlaps, 1)
+	}
+	defer atomic.AddInt32(&w.writing, -1)
+	for i := 0; i < len(data); i += 8 {
+		end := i + 8
+		if end > len(data) {
+			end = len(data)
+		}
+		w.lock.Lock()
+		w.buffer.Write(data[i:end])
+		w.lock.Unlock()
+		runtime.Gosched()
+	}
+	return len(data), nil
+}
+
+// TestConcurrentLinesAreIntact logs from many goroutines, through the
+// Logger and its children, to a non-atomic writer and checks that every
+// line is written whole and exactly once.
+func TestConcurrentLinesAreIntact(t *testing.T) {
+	const (
+		goroutines = 32
+		messages   = 200
+	)
+	writer := &chunkedWriter{}
+	logger := NewLogger(writer, WithEncoder(&LogfmtEncoder{}), WithCaller(logging.CallerOff))
+	logger.SetLevel(logging.LevelTrace)
+	payload := strings.Repeat("x", 256)
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		child := logger.Named(fmt.Sprintf("g%d", g)).With("payload", payload)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < messages; i++ {
+				child.Infow("message", "i", i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if overlaps := atomic.LoadInt32(&writer.overlaps); overlaps != 0 {
+		t.Errorf("%d writes overlapped", overlaps)
+	}
+	line := regexp.MustCompile(`^time=\S+ level=info logger=g(\d+) msg=message payload=(x+) i=(\d+)$`)
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(&writer.buffer)
+	scanner.Buffer(make([]byte, 0, 4096), 1024*1024)
+	for scanner.Scan() {
+		match := line.FindStringSubmatch(scanner.Text())
+		if match == nil || match[2] != payload {
+			t.Fatalf("corrupted line: %.200q", scanner.Text())
+		}
+		key := match[1] + "/" + match[3]
+		if seen[key] {
+			t.Fatalf("duplicated line: %q", scanner.Text())
+		}
+		seen[key] = true
+	}
+	for g := 0; g < goroutines; g++ {
+		for i := 0; i < messages; i++ {
+			if key := strconv.Itoa(g) + "/" + strconv.Itoa(i); !seen[key] {
+				t.Fatalf("missing line for goroutine %d, message %d", g, i)
+			}
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package tee
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/dihedron/go-log-facade/logging"
+)
+
+// Sink is a Logger receiving the entries of a tee Logger, along with the
+// minimum level of the entries it receives.
+type Sink struct {
+	Logger logging.Logger
+	Level  logging.Level
+}
+
+// Logger is a logger that fans out each message to several Loggers, each
+// one with its own minimum level; messages must also pass the Logger's
+// own level, which defaults to the global level.
+type Logger struct {
+	logging.Dispatcher
+	sinks []Sink
+	level *logging.LevelVar
+}
+
+// NewLogger returns a Logger writing to all the given sinks.
+func NewLogger(sinks ...Sink) *Logger {
+	l := &Logger{
+		sinks: sinks,
+		level: logging.NewLevelVar(),
+	}
+	l.Dispatcher = logging.Dispatcher{Handler: l}
+	return l
+}
+
+func (l *Logger) SetLevel(level logging.Level) {
+	l.level.Set(level)
+}
+
+func (l *Logger) GetLevel() *logging.Level {
+	// return the per-instance logging level if set, the global level otherwise
+	return l.level.Pointer()
+}
+
+func (l *Logger) ResetLevel() {
+	l.level.Reset()
+}
+
+// Enabled returns whether messages at the given level would be written
+// by at least one of the sinks.
+func (l *Logger) Enabled(level logging.Level) bool {
+	if !l.level.Enabled(level) {
+		return false
+	}
+	for _, sink := range l.sinks {
+		if level >= sink.Level && sink.Logger.Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+// With returns a tee Logger whose sinks are the children returned by the
+// sinks' With; it shares the logging level of its parent.
+func (l *Logger) With(keysAndValues ...interface{}) logging.Logger {
+	return l.derive(func(logger logging.Logger) logging.Logger {
+		return logger.With(keysAndValues...)
+	})
+}
+
+// Named returns a tee Logger whose sinks are the children returned by the
+// sinks' Named; it shares the logging level of its parent.
+func (l *Logger) Named(name string) logging.Logger {
+	return l.derive(func(logger logging.Logger) logging.Logger {
+		return logger.Named(name)
+	})
+}
+
+// AddCallerSkip returns a tee Logger that reports as caller the function
+// skip frames further up the stack; it shares the logging level of its
+// parent.
+func (l *Logger) AddCallerSkip(skip int) logging.Logger {
+	child := l.derive(same)
+	child.CallerSkip += skip
+	return child
+}
+
+// WithCallerPC returns a tee Logger that reports the given program counter
+// as the caller of all its logging calls; it shares the logging level of
+// its parent.
+func (l *Logger) WithCallerPC(pc uintptr) logging.Logger {
+	child := l.derive(same)
+	child.CallerPC = pc
+	return child
+}
+
+// StackLevel returns the lowest stack level of the sinks, so that the
+// stack trace of the call is captured for all the sinks that need it.
+func (l *Logger) StackLevel() logging.Level {
+	level := logging.LevelOff
+	for _, sink := range l.sinks {
+		if s, ok := sink.Logger.(logging.StackLogger); ok {
+			level = min(level, max(s.StackLevel(), sink.Level))
+		}
+	}
+	return level
+}
+
+// WithStack returns a tee Logger that reports the given stack trace to
+// the sinks for the entries at or above their stack level; it shares the
+// logging level of its parent.
+func (l *Logger) WithStack(stack logging.StackTrace) logging.Logger {
+	child := l.derive(same)
+	child.Stack = stack
+	return child
+}
+
+// WithTime returns a tee Logger that reports the given time to the sinks
+// as the time of all its entries; it shares the logging level of its
+// parent.
+func (l *Logger) WithTime(t time.Time) logging.Logger {
+	child := l.derive(same)
+	child.Time = t
+	return child
+}
+
+// WithoutExit returns a tee Logger whose Fatal methods replay the entry
+// onto the sinks without exiting; sinks that are not logging.ExitLoggers
+// still exit. It shares the logging level of its parent.
+func (l *Logger) WithoutExit() logging.Logger {
+	child := l.derive(same)
+	child.NoExit = true
+	return child
+}
+
+// Handle replays the entry onto all the sinks accepting its level; fatal
+// entries are replayed onto all sinks before exiting, through their
+// WithoutExit children if they are logging.ExitLoggers and last if not,
+// and panic entries before panicking.
+func (l *Logger) Handle(entry *logging.Entry) {
+	switch entry.Level {
+	case logging.LevelPanic:
+		for _, sink := range l.sinks {
+			if entry.Level >= sink.Level {
+				replayPanic(entry, sink.Logger)
+			}
+		}
+		panic(entry.Message())
+	case logging.LevelFatal:
+		// the sinks that are not ExitLoggers exit as soon as they receive
+		// the entry, so they come last
+		var exiting []logging.Logger
+		for _, sink := range l.sinks {
+			if entry.Level >= sink.Level {
+				if logger, ok := logging.WithoutExit(sink.Logger); ok {
+					entry.Replay(logger)
+				} else {
+					exiting = append(exiting, sink.Logger)
+				}
+			}
+		}
+		for _, logger := range exiting {
+			entry.Replay(logger)
+		}
+		if !l.NoExit {
+			logging.Exit(1)
+		}
+	default:
+		for _, sink := range l.sinks {
+			if entry.Level >= sink.Level {
+				entry.Replay(sink.Logger)
+			}
+		}
+	}
+}
+
+// Sync flushes all the sinks that support it, returning all the errors.
+func (l *Logger) Sync() error {
+	var errs []error
+	for _, sink := range l.sinks {
+		if syncer, ok := sink.Logger.(interface{ Sync() error }); ok {
+			if err := syncer.Sync(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes all the sinks that support it, returning all the errors.
+func (l *Logger) Close() error {
+	var errs []error
+	for _, sink := range l.sinks {
+		if closer, ok := sink.Logger.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (l *Logger) derive(f func(logging.Logger) logging.Logger) *Logger {
+	child := &Logger{
+		sinks: make([]Sink, len(l.sinks)),
+		level: l.level,
+	}
+	for i, sink := range l.sinks {
+		child.sinks[i] = Sink{Logger: f(sink.Logger), Level: sink.Level}
+	}
+	child.Dispatcher = l.Dispatcher.WithHandler(child)
+	return child
+}
+
+// same returns the Logger itself, for deriving children with the same sinks.
+func same(logger logging.Logger) logging.Logger {
+	return logger
+}
+
+// replayPanic replays a panic entry, recovering from the panic so that
+// the following sinks receive the entry too.
+func replayPanic(entry *logging.Entry, logger logging.Logger) {
+	defer func() {
+		_ = recover()
+	}()
+	entry.Replay(logger)
+}
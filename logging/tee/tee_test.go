@@ -0,0 +1,90 @@
+package tee
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dihedron/go-log-facade/logging"
+	"github.com/dihedron/go-log-facade/logging/stream"
+)
+
+// foreign hides the WithoutExit method of the Logger it embeds.
+type foreign struct {
+	logging.Logger
+}
+
+// panicking is a sink whose Fatal methods panic.
+type panicking struct {
+	logging.Logger
+}
+
+func (panicking) Fatalw(msg string, keysAndValues ...interface{}) {
+	panic(msg)
+}
+
+func exits(t *testing.T) *int {
+	count := 0
+	previous := logging.SetExitFunc(func(int) { count++ })
+	t.Cleanup(func() { logging.SetExitFunc(previous) })
+	return &count
+}
+
+func TestFatalExitsOnce(t *testing.T) {
+	count := exits(t)
+	buffer := &bytes.Buffer{}
+	sink := stream.NewLogger(buffer, stream.WithEncoder(&stream.LogfmtEncoder{}), stream.WithCaller(logging.CallerOff))
+	logger := NewLogger(
+		Sink{Logger: foreign{sink.Named("foreign")}, Level: logging.LevelTrace},
+		Sink{Logger: sink.Named("first"), Level: logging.LevelTrace},
+		Sink{Logger: sink.Named("second"), Level: logging.LevelError},
+	)
+
+	logger.Fatalw("message")
+
+	var names []string
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		names = append(names, strings.Fields(line)[2])
+	}
+	if got, want := strings.Join(names, " "), "logger=first logger=second logger=foreign"; got != want {
+		t.Errorf("sinks received the entry in order %q, want %q", got, want)
+	}
+	// once by the foreign sink, once by the tee Logger
+	if *count != 2 {
+		t.Errorf("exited %d times, want 2", *count)
+	}
+
+	*count = 0
+	logger = NewLogger(
+		Sink{Logger: sink.Named("first"), Level: logging.LevelTrace},
+		Sink{Logger: sink.Named("second"), Level: logging.LevelTrace},
+	)
+	logger.Fatalw("message")
+	if *count != 1 {
+		t.Errorf("exited %d times without foreign sinks, want 1", *count)
+	}
+	logger.WithoutExit().Fatalw("message")
+	if *count != 1 {
+		t.Errorf("the WithoutExit child exited")
+	}
+}
+
+func TestFatalDoesNotAffectOtherLoggers(t *testing.T) {
+	count := exits(t)
+	sink := stream.NewLogger(&bytes.Buffer{})
+	logger := NewLogger(Sink{Logger: panicking{sink}, Level: logging.LevelTrace})
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("the sink did not panic")
+			}
+		}()
+		logger.Fatalw("message")
+	}()
+
+	sink.Fatal("message")
+	if *count != 1 {
+		t.Errorf("Fatal exited %d times after a sink panicked, want 1", *count)
+	}
+}
@@ -1,9 +1,6 @@
 package test
 
 import (
-	"bytes"
-	"fmt"
-	"runtime"
 	"strings"
 	"testing"
 
@@ -13,48 +10,124 @@ import (
 // Logger wraps the Golang testing framework logger.
 type Logger struct {
 	t      *testing.T
-	caller bool
-	level  *logging.Level
+	caller logging.Caller
+	skip   int
+	pc     uintptr
+	level  *logging.LevelVar
+	name   string
+	fields []interface{}
+	raw    bool
+	noexit bool
 }
 
-// NewLogger returns a Logger wrapping a testing logger.
-func NewLogger(t *testing.T) *Logger {
-	return &Logger{
-		t:      t,
-		caller: false,
+// Option is a functional option for configuring a testing Logger.
+type Option func(*Logger)
+
+// WithoutEscaping disables the escaping of line breaks and control
+// characters in names and messages (see logging.Escape), which otherwise
+// prevents untrusted input from forging entries.
+func WithoutEscaping() Option {
+	return func(l *Logger) {
+		l.raw = true
+	}
+}
+
+// WithCaller sets how the location of the logging calls is reported; the
+// default is logging.CallerOff.
+func WithCaller(caller logging.Caller) Option {
+	return func(l *Logger) {
+		l.caller = caller
 	}
 }
 
-// NewLoggerWithStack returns a Logger wrapping a testing logger
-// and printing the curernt.
-func NewLoggerWithCaller(t *testing.T) *Logger {
-	return &Logger{
+// NewLogger returns a Logger wrapping a testing logger.
+func NewLogger(t *testing.T, options ...Option) *Logger {
+	l := &Logger{
 		t:      t,
-		caller: true,
+		caller: logging.CallerOff,
+		level:  logging.NewLevelVar(),
+	}
+	for _, option := range options {
+		option(l)
 	}
+	return l
+}
+
+// NewLoggerWithCaller returns a Logger wrapping a testing logger
+// and printing the full location of the logging calls.
+func NewLoggerWithCaller(t *testing.T, options ...Option) *Logger {
+	return NewLogger(t, append([]Option{WithCaller(logging.CallerFull)}, options...)...)
 }
 
 func (l *Logger) SetLevel(level logging.Level) {
-	l.level = &level
+	l.level.Set(level)
 }
 
 func (l *Logger) GetLevel() *logging.Level {
-	if l.level != nil {
-		// there's a specific logging level for this logger
-		return l.level
-	}
-	// there is no per-instance logging level, return the global level
-	level := logging.GetGlobalLevel()
-	return &level
+	// return the per-instance logging level if set, the global level otherwise
+	return l.level.Pointer()
 }
 
 func (l *Logger) ResetLevel() {
-	l.level = nil
+	l.level.Reset()
+}
+
+// Enabled returns whether messages at the given level would be written.
+func (l *Logger) Enabled(level logging.Level) bool {
+	return l.level.Enabled(level)
+}
+
+// With returns a child Logger that adds the given key/value pairs to
+// every message; the child shares the logging level of its parent.
+func (l *Logger) With(keysAndValues ...interface{}) logging.Logger {
+	child := *l
+	child.fields = append(l.fields[:len(l.fields):len(l.fields)], keysAndValues...)
+	return &child
+}
+
+// Named returns a child Logger whose name is the given name appended
+// to the parent's name.
+func (l *Logger) Named(name string) logging.Logger {
+	child := *l
+	if l.name != "" {
+		child.name = l.name + "." + name
+	} else {
+		child.name = name
+	}
+	return &child
+}
+
+// AddCallerSkip returns a child Logger that reports as caller the function
+// skip frames further up the stack; it shares the logging level of its
+// parent.
+func (l *Logger) AddCallerSkip(skip int) logging.Logger {
+	child := *l
+	child.skip += skip
+	return &child
+}
+
+// WithCallerPC returns a child Logger that reports the given program
+// counter as the caller of all its logging calls; it shares the logging
+// level of its parent.
+func (l *Logger) WithCallerPC(pc uintptr) logging.Logger {
+	child := *l
+	child.pc = pc
+	return &child
+}
+
+// WithoutExit returns a child Logger whose Fatal methods mark the test as
+// failed without stopping it, as t.Error does; it shares the logging level
+// of its parent.
+func (l *Logger) WithoutExit() logging.Logger {
+	child := *l
+	child.noexit = true
+	return &child
 }
 
 // Trace logs a message at LevelTrace level.
 func (l *Logger) Trace(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelTrace {
+	l.t.Helper()
+	if l.level.Enabled(logging.LevelTrace) {
 		message := l.format("TRC", args...)
 		l.t.Log(message)
 	}
@@ -62,15 +135,26 @@ func (l *Logger) Trace(args ...interface{}) {
 
 // Tracef logs a message at LevelTrace level.
 func (l *Logger) Tracef(msg string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelTrace {
+	l.t.Helper()
+	if l.level.Enabled(logging.LevelTrace) {
 		message := l.formatf("TRC", msg, args...)
 		l.t.Log(message)
 	}
 }
 
+// Tracew logs a message with the given key/value pairs at LevelTrace level.
+func (l *Logger) Tracew(msg string, keysAndValues ...interface{}) {
+	l.t.Helper()
+	if l.level.Enabled(logging.LevelTrace) {
+		message := l.formatw("TRC", msg, keysAndValues...)
+		l.t.Log(message)
+	}
+}
+
 // Debug logs a message at LevelDebug level.
 func (l *Logger) Debug(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelDebug {
+	l.t.Helper()
+	if l.level.Enabled(logging.LevelDebug) {
 		message := l.format("DBG", args...)
 		l.t.Log(message)
 	}
@@ -78,15 +162,26 @@ func (l *Logger) Debug(args ...interface{}) {
 
 // Debugf logs a message at LevelDebug level.
 func (l *Logger) Debugf(msg string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelDebug {
+	l.t.Helper()
+	if l.level.Enabled(logging.LevelDebug) {
 		message := l.formatf("DBG", msg, args...)
 		l.t.Log(message)
 	}
 }
 
+// Debugw logs a message with the given key/value pairs at LevelDebug level.
+func (l *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	l.t.Helper()
+	if l.level.Enabled(logging.LevelDebug) {
+		message := l.formatw("DBG", msg, keysAndValues...)
+		l.t.Log(message)
+	}
+}
+
 // Info logs a message at LevelInfo level.
 func (l *Logger) Info(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelInfo {
+	l.t.Helper()
+	if l.level.Enabled(logging.LevelInfo) {
 		message := l.format("INF", args...)
 		l.t.Log(message)
 	}
@@ -94,15 +189,26 @@ func (l *Logger) Info(args ...interface{}) {
 
 // Infof logs a message at LevelInfo level.
 func (l *Logger) Infof(msg string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelInfo {
+	l.t.Helper()
+	if l.level.Enabled(logging.LevelInfo) {
 		message := l.formatf("INF", msg, args...)
 		l.t.Log(message)
 	}
 }
 
+// Infow logs a message with the given key/value pairs at LevelInfo level.
+func (l *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	l.t.Helper()
+	if l.level.Enabled(logging.LevelInfo) {
+		message := l.formatw("INF", msg, keysAndValues...)
+		l.t.Log(message)
+	}
+}
+
 // Warn logs a message at LevelWarn level.
 func (l *Logger) Warn(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelWarn {
+	l.t.Helper()
+	if l.level.Enabled(logging.LevelWarn) {
 		message := l.format("WRN", args...)
 		l.t.Log(message)
 	}
@@ -110,15 +216,26 @@ func (l *Logger) Warn(args ...interface{}) {
 
 // Warnf logs a message at LevelWarn level.
 func (l *Logger) Warnf(msg string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelWarn {
+	l.t.Helper()
+	if l.level.Enabled(logging.LevelWarn) {
 		message := l.formatf("WRN", msg, args...)
 		l.t.Log(message)
 	}
 }
 
+// Warnw logs a message with the given key/value pairs at LevelWarn level.
+func (l *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	l.t.Helper()
+	if l.level.Enabled(logging.LevelWarn) {
+		message := l.formatw("WRN", msg, keysAndValues...)
+		l.t.Log(message)
+	}
+}
+
 // Error logs a message at LevelError level.
 func (l *Logger) Error(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelError {
+	l.t.Helper()
+	if l.level.Enabled(logging.LevelError) {
 		message := l.format("ERR", args...)
 		l.t.Log(message)
 	}
@@ -126,43 +243,136 @@ func (l *Logger) Error(args ...interface{}) {
 
 // Errorf logs a message at LevelError level.
 func (l *Logger) Errorf(msg string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelError {
+	l.t.Helper()
+	if l.level.Enabled(logging.LevelError) {
 		message := l.formatf("ERR", msg, args...)
 		l.t.Log(message)
 	}
 }
 
-func (l *Logger) format(level string, args ...interface{}) string {
-	var buffer bytes.Buffer
-	for argNum, arg := range args {
-		if argNum > 0 {
-			buffer.WriteString(" ")
-		}
-		buffer.WriteString(fmt.Sprintf("%v", arg))
+// Errorw logs a message with the given key/value pairs at LevelError level.
+func (l *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	l.t.Helper()
+	if l.level.Enabled(logging.LevelError) {
+		message := l.formatw("ERR", msg, keysAndValues...)
+		l.t.Log(message)
+	}
+}
+
+// Panic logs a message at LevelPanic level, then panics.
+func (l *Logger) Panic(args ...interface{}) {
+	l.t.Helper()
+	message := l.format("PNC", args...)
+	if l.level.Enabled(logging.LevelPanic) {
+		l.t.Log(message)
 	}
-	extra := ""
-	if l.caller {
-		pc, _, _, ok := runtime.Caller(2)
-		details := runtime.FuncForPC(pc)
+	panic(message)
+}
+
+// Panicf logs a message at LevelPanic level, then panics.
+func (l *Logger) Panicf(msg string, args ...interface{}) {
+	l.t.Helper()
+	message := l.formatf("PNC", msg, args...)
+	if l.level.Enabled(logging.LevelPanic) {
+		l.t.Log(message)
+	}
+	panic(message)
+}
+
+// Panicw logs a message with the given key/value pairs at LevelPanic
+// level, then panics.
+func (l *Logger) Panicw(msg string, keysAndValues ...interface{}) {
+	l.t.Helper()
+	message := l.formatw("PNC", msg, keysAndValues...)
+	if l.level.Enabled(logging.LevelPanic) {
+		l.t.Log(message)
+	}
+	panic(message)
+}
+
+// Fatal logs a message at LevelFatal level, then stops the test by
+// calling t.Fatal.
+func (l *Logger) Fatal(args ...interface{}) {
+	l.t.Helper()
+	l.fatal(l.format("FTL", args...))
+}
 
-		if ok && details != nil {
-			line, no := details.FileLine(pc)
-			extra = fmt.Sprintf(" (%s:%d)", line, no)
-		}
+// Fatalf logs a message at LevelFatal level, then stops the test by
+// calling t.Fatal.
+func (l *Logger) Fatalf(msg string, args ...interface{}) {
+	l.t.Helper()
+	l.fatal(l.formatf("FTL", msg, args...))
+}
+
+// Fatalw logs a message with the given key/value pairs at LevelFatal
+// level, then stops the test by calling t.Fatal.
+func (l *Logger) Fatalw(msg string, keysAndValues ...interface{}) {
+	l.t.Helper()
+	l.fatal(l.formatw("FTL", msg, keysAndValues...))
+}
+
+// fatal logs the message, if LevelFatal is enabled, and stops the test,
+// unless the Logger was derived with WithoutExit.
+func (l *Logger) fatal(message string) {
+	l.t.Helper()
+	enabled := l.level.Enabled(logging.LevelFatal)
+	switch {
+	case l.noexit && enabled:
+		l.t.Error(message)
+	case l.noexit:
+		l.t.Fail()
+	case enabled:
+		l.t.Fatal(message)
+	default:
+		l.t.FailNow()
 	}
-	message := fmt.Sprintf("[%s] %s%s", level, buffer.String(), extra)
-	return strings.TrimRight(message, "\n\r")
+}
+
+func (l *Logger) format(level string, args ...interface{}) string {
+	l.t.Helper()
+	return l.decorate(level, l.callerPC(), logging.Sprint(args...))
 }
 
 func (l *Logger) formatf(level string, msg string, args ...interface{}) string {
-	message := strings.TrimRight(fmt.Sprintf("["+level+"] "+strings.TrimSpace(msg), args...), "\n\r")
-	if l.caller {
-		pc, _, _, ok := runtime.Caller(2)
-		details := runtime.FuncForPC(pc)
-		if ok && details != nil {
-			line, no := details.FileLine(pc)
-			message = fmt.Sprintf("%s (%s:%d)", message, line, no)
-		}
-	}
-	return message
+	l.t.Helper()
+	return l.decorate(level, l.callerPC(), logging.Sprintf(strings.TrimSpace(msg), args...))
+}
+
+func (l *Logger) formatw(level string, msg string, keysAndValues ...interface{}) string {
+	l.t.Helper()
+	return l.decorate(level, l.callerPC(), strings.TrimSpace(msg), keysAndValues...)
+}
+
+// callerPC returns the program counter of the caller of the logging
+// method, as configured, or zero if the caller is not reported.
+func (l *Logger) callerPC() uintptr {
+	if l.pc != 0 || l.caller == logging.CallerOff {
+		return l.pc
+	}
+	// skip callerPC, the format function and the logging method
+	return logging.CallerPC(3 + l.skip)
+}
+
+// decorate adds the level, the logger name, the bound and given key/value
+// pairs and the location of the call to the message, followed by the
+// stack trace if requested.
+func (l *Logger) decorate(level string, pc uintptr, message string, keysAndValues ...interface{}) string {
+	message = strings.TrimRight(message, "\n\r")
+	if l.name != "" {
+		message = l.name + ": " + message
+	}
+	if !l.raw {
+		message = logging.Escape(message)
+	}
+	keysAndValues, stack := logging.SplitStackTrace(keysAndValues)
+	if fields := logging.FormatFields(append(l.fields[:len(l.fields):len(l.fields)], keysAndValues...)...); fields != "" {
+		message = message + " " + fields
+	}
+	if location := logging.FormatFrame(l.caller.Frame(pc)); location != "" {
+		message = message + " (" + location + ")"
+	}
+	if stack != nil {
+		message = message + "\n\t" + strings.ReplaceAll(stack.String(), "\n", "\n\t")
+	}
+	return "[" + level + "] " + message
 }
@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"strconv"
+	"unicode/utf8"
+)
+
+// Truncate returns the string cut to at most max bytes, without splitting
+// a UTF-8 sequence, and followed by a marker reporting how much has been
+// removed, e.g. "…[truncated 1.2MB]"; the marker is not counted in max.
+// Strings not longer than max, and all strings if max is negative, are
+// returned as they are.
+func Truncate(s string, max int) string {
+	if max < 0 || len(s) <= max {
+		return s
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…[truncated " + formatSize(len(s)-cut) + "]"
+}
+
+// formatSize renders a number of bytes in a human-readable form, e.g.
+// "512B", "3.4KB" or "1.2MB".
+func formatSize(n int) string {
+	const unit = 1024
+	if n < unit {
+		return strconv.Itoa(n) + "B"
+	}
+	value, suffix := float64(n)/unit, "KB"
+	for _, s := range []string{"MB", "GB", "TB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, s
+	}
+	return strconv.FormatFloat(value, 'f', 1, 64) + suffix
+}
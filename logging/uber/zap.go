@@ -7,16 +7,63 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/dihedron/go-log-facade/logging"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // Logger is an adapter that allows to log using Uber's Zap
 // wherever a Logger interface is expected.
 type Logger struct {
 	logger *zap.Logger
-	level  *logging.Level
+	level  *logging.LevelVar
+	stack  logging.Level
+	caller logging.Caller
+	skip   int
+	pc     uintptr
+	raw    bool
+	trace  logging.StackTrace
+	time   time.Time
+	noexit bool
+}
+
+// Option is a functional option for configuring a Zap Logger.
+type Option func(*Logger)
+
+// WithoutEscaping disables the escaping of line breaks and control
+// characters in messages and logger names (see logging.Escape), which
+// otherwise prevents untrusted input from forging entries; it is the
+// default when the configuration uses the JSON encoding, which is escaped
+// anyway. Field values are always encoded as JSON by zap.
+func WithoutEscaping() Option {
+	return func(l *Logger) {
+		l.raw = true
+	}
+}
+
+// WithStackTrace adds the stack trace of the call, as a "stack" field, to
+// entries at or above the given level; by default, stack traces are only
+// added on demand, with logging.Stack. They replace zap's own stack traces,
+// which include the frames of the logging facade.
+func WithStackTrace(level logging.Level) Option {
+	return func(l *Logger) {
+		l.stack = level
+		l.logger = l.logger.WithOptions(zap.AddStacktrace(zap.LevelEnablerFunc(func(zapcore.Level) bool {
+			return false
+		})))
+	}
+}
+
+// WithCaller sets how the location of the logging calls is reported, in
+// zap's caller field, which is formatted by the configured encoder; the
+// default is logging.CallerFull, or logging.CallerOff if the configuration
+// disables the caller.
+func WithCaller(caller logging.Caller) Option {
+	return func(l *Logger) {
+		l.caller = caller
+	}
 }
 
 var (
@@ -27,7 +74,7 @@ var (
 // NewLogger initialises a Zap logger, either by locating and loading
 // a configuration fil from disk, or by assuming the sane defaults
 // for a production environment.
-func NewLogger() (*Logger, error) {
+func NewLogger(options ...Option) (*Logger, error) {
 
 	// check if there's a file called brokerd-log.json aside the
 	// application excutable; if so, load it as it contains the
@@ -46,10 +93,7 @@ func NewLogger() (*Logger, error) {
 		}
 		Restore = zap.ReplaceGlobals(logger)
 		logger.Info("application starting with custom log configuration")
-		return &Logger{
-			logger: logger.WithOptions(zap.AddCallerSkip(1)),
-			// logger: logger,
-		}, nil
+		return newLogger(logger, configuration.Encoding, append([]Option{WithCaller(callerOf(configuration))}, options...)...), nil
 	}
 	// configuration does not exist, use default
 	configuration = zap.NewProductionConfig()
@@ -64,101 +108,349 @@ func NewLogger() (*Logger, error) {
 	}
 	Restore = zap.ReplaceGlobals(logger)
 	logger.Info("application starting with default log configuration")
+	return newLogger(logger, configuration.Encoding, append([]Option{WithCaller(callerOf(configuration))}, options...)...), nil
+}
 
-	return &Logger{
-		logger: logger.WithOptions(zap.AddCallerSkip(1)),
-		//logger: logger,
-	}, nil
+// newLogger returns a Logger writing to the given zap Logger, whose
+// messages and names are escaped unless the encoding is JSON; zap's own
+// caller reporting is replaced by the Logger's, and its fatal entries
+// return, so that the application exits through logging.Exit.
+func newLogger(logger *zap.Logger, encoding string, options ...Option) *Logger {
+	l := &Logger{
+		logger: logger.WithOptions(zap.WithCaller(false), zap.WithFatalHook(noExit{})),
+		level:  logging.NewLevelVar(),
+		stack:  logging.LevelOff,
+		caller: logging.CallerFull,
+		raw:    encoding == "json",
+	}
+	for _, option := range options {
+		option(l)
+	}
+	return l
 }
 
 func (l *Logger) SetLevel(level logging.Level) {
-	l.level = &level
+	l.level.Set(level)
 }
 
 func (l *Logger) GetLevel() *logging.Level {
-	if l.level != nil {
-		// there's a specific logging level for this logger
-		return l.level
-	}
-	// there is no per-instance logging level, return the global level
-	level := logging.GetGlobalLevel()
-	return &level
+	// return the per-instance logging level if set, the global level otherwise
+	return l.level.Pointer()
 }
 
 func (l *Logger) ResetLevel() {
-	l.level = nil
+	l.level.Reset()
+}
+
+// Enabled returns whether messages at the given level would be written,
+// according to both this Logger's level and the zap core.
+func (l *Logger) Enabled(level logging.Level) bool {
+	return level < logging.LevelOff && l.level.Enabled(level) && l.logger.Core().Enabled(levels[level])
+}
+
+// With returns a child Logger that adds the given key/value pairs as
+// fields to every message; the child shares the parent's zap core and
+// shares its logging level.
+func (l *Logger) With(keysAndValues ...interface{}) logging.Logger {
+	child := *l
+	child.logger = l.logger.Sugar().With(keysAndValues...).Desugar()
+	return &child
+}
+
+// Named returns a child Logger whose name is the given name appended
+// to the parent's name, using zap's native naming.
+func (l *Logger) Named(name string) logging.Logger {
+	child := *l
+	if !l.raw {
+		name = logging.Escape(name)
+	}
+	child.logger = l.logger.Named(name)
+	return &child
+}
+
+// AddCallerSkip returns a child Logger that reports as caller the function
+// skip frames further up the stack; it shares the logging level of its
+// parent.
+func (l *Logger) AddCallerSkip(skip int) logging.Logger {
+	child := *l
+	child.skip += skip
+	return &child
+}
+
+// WithCallerPC returns a child Logger that reports the given program
+// counter as the caller of all its logging calls; it shares the logging
+// level of its parent.
+func (l *Logger) WithCallerPC(pc uintptr) logging.Logger {
+	child := *l
+	child.pc = pc
+	return &child
+}
+
+// WithoutExit returns a child Logger whose Fatal methods log the message
+// and return instead of exiting; it shares the logging level of its
+// parent.
+func (l *Logger) WithoutExit() logging.Logger {
+	child := *l
+	child.noexit = true
+	return &child
+}
+
+// StackLevel returns the level at or above which entries get the stack
+// trace of the call.
+func (l *Logger) StackLevel() logging.Level {
+	return l.stack
+}
+
+// WithStack returns a child Logger that reports the given stack trace,
+// instead of capturing its own, for the entries at or above its stack
+// level; it shares the logging level of its parent.
+func (l *Logger) WithStack(stack logging.StackTrace) logging.Logger {
+	child := *l
+	child.trace = stack
+	return &child
+}
+
+// WithTime returns a child Logger that reports the given time as the time
+// of all its entries; it shares the logging level of its parent.
+func (l *Logger) WithTime(t time.Time) logging.Logger {
+	child := *l
+	child.time = t
+	return &child
 }
 
 // Trace logs a message at LevelTrace level.
 func (l *Logger) Trace(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelTrace {
-		l.logger.Sugar().Debug(args...)
+	if l.level.Enabled(logging.LevelTrace) {
+		l.write(logging.LevelTrace, fmt.Sprint(logging.Resolve(args)...), nil)
 	}
 }
 
 // Tracef logs a message at LevelTrace level.
 func (l *Logger) Tracef(format string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelTrace {
-		l.logger.Sugar().Debugf(format, args...)
+	if l.level.Enabled(logging.LevelTrace) {
+		l.write(logging.LevelTrace, logging.Sprintf(format, args...), nil)
+	}
+}
+
+// Tracew logs a message with the given key/value pairs at LevelTrace level.
+func (l *Logger) Tracew(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelTrace) {
+		l.write(logging.LevelTrace, msg, keysAndValues)
 	}
 }
 
 // Debug logs a message at LevelDebug level.
 func (l *Logger) Debug(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelDebug {
-		l.logger.Sugar().Debug(args...)
+	if l.level.Enabled(logging.LevelDebug) {
+		l.write(logging.LevelDebug, fmt.Sprint(logging.Resolve(args)...), nil)
 	}
 }
 
 // Debugf logs a message at LevelDebug level.
 func (l *Logger) Debugf(format string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelDebug {
-		l.logger.Sugar().Debugf(format, args...)
+	if l.level.Enabled(logging.LevelDebug) {
+		l.write(logging.LevelDebug, logging.Sprintf(format, args...), nil)
+	}
+}
+
+// Debugw logs a message with the given key/value pairs at LevelDebug level.
+func (l *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelDebug) {
+		l.write(logging.LevelDebug, msg, keysAndValues)
 	}
 }
 
 // Info logs a message at LevelInfo level.
 func (l *Logger) Info(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelInfo {
-		l.logger.Sugar().Info(args...)
+	if l.level.Enabled(logging.LevelInfo) {
+		l.write(logging.LevelInfo, fmt.Sprint(logging.Resolve(args)...), nil)
 	}
 }
 
 // Infof logs a message at LevelInfo level.
 func (l *Logger) Infof(format string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelInfo {
-		l.logger.Sugar().Infof(format, args...)
+	if l.level.Enabled(logging.LevelInfo) {
+		l.write(logging.LevelInfo, logging.Sprintf(format, args...), nil)
+	}
+}
+
+// Infow logs a message with the given key/value pairs at LevelInfo level.
+func (l *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelInfo) {
+		l.write(logging.LevelInfo, msg, keysAndValues)
 	}
 }
 
 // Warn logs a message at LevelWarn level.
 func (l *Logger) Warn(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelWarn {
-		l.logger.Sugar().Warn(args...)
+	if l.level.Enabled(logging.LevelWarn) {
+		l.write(logging.LevelWarn, fmt.Sprint(logging.Resolve(args)...), nil)
 	}
 }
 
 // Warnf logs a message at LevelWarn level.
 func (l *Logger) Warnf(format string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelWarn {
-		l.logger.Sugar().Warnf(format, args...)
+	if l.level.Enabled(logging.LevelWarn) {
+		l.write(logging.LevelWarn, logging.Sprintf(format, args...), nil)
+	}
+}
+
+// Warnw logs a message with the given key/value pairs at LevelWarn level.
+func (l *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelWarn) {
+		l.write(logging.LevelWarn, msg, keysAndValues)
 	}
 }
 
 // Error logs a message at LevelError level.
 func (l *Logger) Error(args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelError {
-		l.logger.Sugar().Error(args...)
+	if l.level.Enabled(logging.LevelError) {
+		l.write(logging.LevelError, fmt.Sprint(logging.Resolve(args)...), nil)
 	}
 }
 
 // Errorf logs a message at LevelError level.
 func (l *Logger) Errorf(format string, args ...interface{}) {
-	if *l.GetLevel() <= logging.LevelError {
-		l.logger.Sugar().Errorf(format, args...)
+	if l.level.Enabled(logging.LevelError) {
+		l.write(logging.LevelError, logging.Sprintf(format, args...), nil)
+	}
+}
+
+// Errorw logs a message with the given key/value pairs at LevelError level.
+func (l *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelError) {
+		l.write(logging.LevelError, msg, keysAndValues)
+	}
+}
+
+// Panic logs a message at LevelPanic level, then panics.
+func (l *Logger) Panic(args ...interface{}) {
+	if l.level.Enabled(logging.LevelPanic) {
+		l.write(logging.LevelPanic, fmt.Sprint(logging.Resolve(args)...), nil)
+	}
+	panic(fmt.Sprint(logging.Resolve(args)...))
+}
+
+// Panicf logs a message at LevelPanic level, then panics.
+func (l *Logger) Panicf(format string, args ...interface{}) {
+	if l.level.Enabled(logging.LevelPanic) {
+		l.write(logging.LevelPanic, logging.Sprintf(format, args...), nil)
+	}
+	panic(logging.Sprintf(format, args...))
+}
+
+// Panicw logs a message with the given key/value pairs at LevelPanic level, then panics.
+func (l *Logger) Panicw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelPanic) {
+		l.write(logging.LevelPanic, msg, keysAndValues)
+	}
+	panic(msg)
+}
+
+// Fatal logs a message at LevelFatal level, flushes the logger and exits.
+func (l *Logger) Fatal(args ...interface{}) {
+	if l.level.Enabled(logging.LevelFatal) {
+		l.write(logging.LevelFatal, fmt.Sprint(logging.Resolve(args)...), nil)
+	}
+	_ = l.logger.Sync()
+	l.exit()
+}
+
+// Fatalf logs a message at LevelFatal level, flushes the logger and exits.
+func (l *Logger) Fatalf(format string, args ...interface{}) {
+	if l.level.Enabled(logging.LevelFatal) {
+		l.write(logging.LevelFatal, logging.Sprintf(format, args...), nil)
+	}
+	_ = l.logger.Sync()
+	l.exit()
+}
+
+// Fatalw logs a message with the given key/value pairs at LevelFatal level, flushes the logger and exits.
+func (l *Logger) Fatalw(msg string, keysAndValues ...interface{}) {
+	if l.level.Enabled(logging.LevelFatal) {
+		l.write(logging.LevelFatal, msg, keysAndValues)
 	}
+	_ = l.logger.Sync()
+	l.exit()
 }
 
+// exit terminates the application, unless the Logger was derived with
+// WithoutExit.
+func (l *Logger) exit() {
+	if !l.noexit {
+		logging.Exit(1)
+	}
+}
+
+// levels maps the facade levels to zap levels.
+var levels = [...]zapcore.Level{
+	logging.LevelTrace: zapcore.DebugLevel,
+	logging.LevelDebug: zapcore.DebugLevel,
+	logging.LevelInfo:  zapcore.InfoLevel,
+	logging.LevelWarn:  zapcore.WarnLevel,
+	logging.LevelError: zapcore.ErrorLevel,
+	logging.LevelPanic: zapcore.PanicLevel,
+	logging.LevelFatal: zapcore.FatalLevel,
+}
+
+// write writes an entry at the given level with the key/value pairs, which
+// are handled as by zap's sugared logger, and the location of the call;
+// the stack trace of the call is added as a field if the level requires
+// it or if it is requested among the key/value pairs. It must be called
+// directly by the logging methods; zap panics after writing LevelPanic
+// entries.
+func (l *Logger) write(level logging.Level, msg string, keysAndValues []interface{}) {
+	keysAndValues, stack := logging.SplitStackTrace(logging.Resolve(keysAndValues))
+	if stack == nil && level >= l.stack {
+		stack = l.trace
+		if stack == nil {
+			stack = logging.CaptureStackTrace()
+		}
+	}
+	if !l.raw {
+		msg = logging.Escape(msg)
+	}
+	logger := l.logger
+	if len(keysAndValues) > 0 {
+		logger = logger.Sugar().With(keysAndValues...).Desugar()
+	}
+	entry := logger.Check(levels[level], msg)
+	if entry == nil {
+		return
+	}
+	pc := l.pc
+	if pc == 0 && l.caller != logging.CallerOff {
+		// skip write and the logging method
+		pc = logging.CallerPC(2 + l.skip)
+	}
+	if !l.time.IsZero() {
+		entry.Time = l.time
+	}
+	if frame := l.caller.Frame(pc); frame.PC != 0 {
+		entry.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+		entry.Caller.Function = frame.Function
+	}
+	if stack != nil {
+		entry.Write(zap.String(logging.StackKey, stack.String()))
+	} else {
+		entry.Write()
+	}
+}
+
+// callerOf returns the default caller reporting for the configuration.
+func callerOf(configuration zap.Config) logging.Caller {
+	if configuration.DisableCaller {
+		return logging.CallerOff
+	}
+	return logging.CallerFull
+}
+
+// noExit is a zap hook that lets Fatal-level entries return after being
+// written, so that the application is terminated through logging.Exit.
+type noExit struct{}
+
+func (noExit) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) {}
+
 func fillForElastic(configuration *zap.Config) {
 	// configuration.EncoderConfig.MessageKey = "message"
 	// configuration.EncoderConfig.LevelKey = "log.level"
@@ -0,0 +1,62 @@
+package uber
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dihedron/go-log-facade/logging"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// forged is a message trying to add a fake entry to the log.
+const forged = "login failed\n2026-01-01T00:00:00.000Z\tINFO\tlogin succeeded"
+
+// newTestLogger returns a Logger writing to the buffer with the given
+// zap encoding.
+func newTestLogger(buffer *bytes.Buffer, encoding string) *Logger {
+	config := zap.NewProductionEncoderConfig()
+	var encoder zapcore.Encoder
+	if encoding == "json" {
+		encoder = zapcore.NewJSONEncoder(config)
+	} else {
+		encoder = zapcore.NewConsoleEncoder(config)
+	}
+	l := newLogger(zap.New(zapcore.NewCore(encoder, zapcore.AddSync(buffer), zap.DebugLevel)), encoding, WithCaller(logging.CallerOff))
+	l.SetLevel(logging.LevelInfo)
+	return l
+}
+
+func TestForgedLines(t *testing.T) {
+	for _, encoding := range []string{"console", "json"} {
+		t.Run(encoding, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			logger := newTestLogger(buffer, encoding).Named("name\nforged").With("with", "value\nforged")
+
+			logger.Info(forged)
+			logger.Infof("%s", forged)
+			logger.Infow(forged, "key\nforged", forged, "error", errors.New(forged))
+
+			lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+			if len(lines) != 3 {
+				t.Fatalf("got %d lines for 3 entries:\n%s", len(lines), buffer.String())
+			}
+			if encoding != "json" {
+				return
+			}
+			// JSON is escaped by zap, so messages are not escaped twice
+			for _, line := range lines {
+				var entry map[string]interface{}
+				if err := json.Unmarshal([]byte(line), &entry); err != nil {
+					t.Fatalf("invalid JSON %q: %v", line, err)
+				}
+				if entry["msg"] != forged || entry["logger"] != "name\nforged" {
+					t.Errorf("message or name altered: %q", line)
+				}
+			}
+		})
+	}
+}
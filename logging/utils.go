@@ -1,6 +1,7 @@
 package logging
 
 import (
+	"bytes"
 	"encoding/json"
 	"runtime"
 
@@ -16,17 +17,40 @@ func GetCallerFrame(skip int) runtime.Frame {
 	//fmt.Printf("%s:%d %s\n", frame.File, frame.Line, frame.Function)
 }
 
+// ToJSON marshals the value to JSON, redacting the fields set with
+// SetRedactedFields.
 func ToJSON(v interface{}) string {
 	data, _ := json.Marshal(v)
+	if fields := getRedactedFields(); fields != nil {
+		data = redactJSON(data, fields)
+	}
 	return string(data)
 }
 
+// ToPrettyJSON marshals the value to indented JSON, redacting the fields
+// set with SetRedactedFields.
 func ToPrettyJSON(v interface{}) string {
 	data, _ := json.MarshalIndent(v, "", "  ")
+	if fields := getRedactedFields(); fields != nil {
+		var buffer bytes.Buffer
+		if json.Indent(&buffer, redactJSON(data, fields), "", "  ") == nil {
+			data = buffer.Bytes()
+		}
+	}
 	return string(data)
 }
 
+// ToYAML marshals the value to YAML, redacting the fields set with
+// SetRedactedFields.
 func ToYAML(v interface{}) string {
+	if fields := getRedactedFields(); fields != nil {
+		var node yaml.Node
+		if err := node.Encode(v); err == nil {
+			redactYAML(&node, fields)
+			data, _ := yaml.Marshal(&node)
+			return string(data)
+		}
+	}
 	data, _ := yaml.Marshal(v)
 	return string(data)
 }
@@ -0,0 +1,148 @@
+package logging
+
+import "time"
+
+// Wrapper implements the level, child and caller methods of the Loggers
+// that decorate another Logger, and their logging methods through the
+// embedded Dispatcher, so that such Loggers only have to provide the
+// Handler and a function deriving their children, e.g.
+//
+//	type Logger struct {
+//		logging.Wrapper
+//		state *state
+//	}
+//
+//	func NewLogger(logger logging.Logger) *Logger {
+//		l := &Logger{state: &state{}}
+//		l.Wrapper = logging.NewWrapper(logger, l, l.derive)
+//		return l
+//	}
+//
+//	// derive returns a child Logger sharing the state of its parent.
+//	func (l *Logger) derive(w logging.Wrapper) logging.Logger {
+//		child := &Logger{state: l.state}
+//		child.Wrapper = w.Bind(child, child.derive)
+//		return child
+//	}
+//
+// All the children share the logging level of the Logger they derive
+// from; Loggers can override Enabled, With and Named, e.g. to transform
+// the key/value pairs, and use Derive to create their children.
+type Wrapper struct {
+	Dispatcher
+	logger Logger
+	level  *LevelVar
+	derive func(Wrapper) Logger
+}
+
+// NewWrapper returns a Wrapper around the given Logger, with its own
+// logging level, passing entries to the given Handler and deriving
+// children with the given function.
+func NewWrapper(logger Logger, handler Handler, derive func(Wrapper) Logger) Wrapper {
+	return Wrapper{
+		Dispatcher: Dispatcher{Handler: handler},
+		logger:     logger,
+		level:      NewLevelVar(),
+		derive:     derive,
+	}
+}
+
+// Bind returns a copy of the Wrapper, with the same wrapped Logger, level
+// and caller settings, passing entries to the given Handler and deriving
+// children with the given function; it is meant to be called by the
+// function deriving children, on the Wrapper it receives.
+func (w Wrapper) Bind(handler Handler, derive func(Wrapper) Logger) Wrapper {
+	w.Dispatcher = w.Dispatcher.WithHandler(handler)
+	w.derive = derive
+	return w
+}
+
+// Unwrap returns the wrapped Logger.
+func (w Wrapper) Unwrap() Logger {
+	return w.logger
+}
+
+// Derive returns a child of the wrapping Logger writing to the given
+// Logger, with the same level and caller settings.
+func (w Wrapper) Derive(logger Logger) Logger {
+	w.logger = logger
+	return w.derive(w)
+}
+
+func (w Wrapper) SetLevel(level Level) {
+	w.level.Set(level)
+}
+
+func (w Wrapper) GetLevel() *Level {
+	// return the per-instance logging level if set, the global level otherwise
+	return w.level.Pointer()
+}
+
+func (w Wrapper) ResetLevel() {
+	w.level.Reset()
+}
+
+// Enabled returns whether messages at the given level would be written
+// by the wrapped Logger.
+func (w Wrapper) Enabled(level Level) bool {
+	return w.level.Enabled(level) && w.logger.Enabled(level)
+}
+
+// With returns a child Logger whose entries are written to the wrapped
+// Logger's With child.
+func (w Wrapper) With(keysAndValues ...interface{}) Logger {
+	return w.Derive(w.logger.With(keysAndValues...))
+}
+
+// Named returns a child Logger whose entries are written to the wrapped
+// Logger's Named child.
+func (w Wrapper) Named(name string) Logger {
+	return w.Derive(w.logger.Named(name))
+}
+
+// AddCallerSkip returns a child Logger that reports as caller the function
+// skip frames further up the stack.
+func (w Wrapper) AddCallerSkip(skip int) Logger {
+	w.CallerSkip += skip
+	return w.derive(w)
+}
+
+// WithCallerPC returns a child Logger that reports the given program
+// counter as the caller of all its logging calls.
+func (w Wrapper) WithCallerPC(pc uintptr) Logger {
+	w.CallerPC = pc
+	return w.derive(w)
+}
+
+// WithoutExit returns a child Logger whose Fatal methods do not exit; its
+// entries are written to the child returned by logging.WithoutExit, so
+// fatal entries still exit if the wrapped Logger is not an ExitLogger.
+func (w Wrapper) WithoutExit() Logger {
+	w.NoExit = true
+	w.logger, _ = WithoutExit(w.logger)
+	return w.derive(w)
+}
+
+// StackLevel returns the stack level of the wrapped Logger, if it is a
+// StackLogger, so that the stack trace of the call is captured before the
+// entry is passed to the Handler; otherwise, it returns LevelOff.
+func (w Wrapper) StackLevel() Level {
+	if s, ok := w.logger.(StackLogger); ok {
+		return s.StackLevel()
+	}
+	return LevelOff
+}
+
+// WithStack returns a child Logger that reports the given stack trace for
+// the entries at or above its stack level.
+func (w Wrapper) WithStack(stack StackTrace) Logger {
+	w.Stack = stack
+	return w.derive(w)
+}
+
+// WithTime returns a child Logger that reports the given time as the time
+// of all its entries.
+func (w Wrapper) WithTime(t time.Time) Logger {
+	w.Time = t
+	return w.derive(w)
+}
@@ -0,0 +1,101 @@
+package logging_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/dihedron/go-log-facade/logging"
+	"github.com/dihedron/go-log-facade/logging/async"
+	"github.com/dihedron/go-log-facade/logging/dedup"
+	"github.com/dihedron/go-log-facade/logging/fingerscrossed"
+	"github.com/dihedron/go-log-facade/logging/limit"
+	"github.com/dihedron/go-log-facade/logging/redact"
+	"github.com/dihedron/go-log-facade/logging/sampling"
+	"github.com/dihedron/go-log-facade/logging/stream"
+)
+
+// wrappers build the Loggers embedding logging.Wrapper around a Logger.
+var wrappers = map[string]func(logging.Logger) logging.Logger{
+	"async": func(logger logging.Logger) logging.Logger { return async.NewLogger(logger) },
+	"dedup": func(logger logging.Logger) logging.Logger { return dedup.NewLogger(logger) },
+	"fingerscrossed": func(logger logging.Logger) logging.Logger {
+		return fingerscrossed.NewLogger(logger, fingerscrossed.WithThreshold(logging.LevelTrace))
+	},
+	"limit":    func(logger logging.Logger) logging.Logger { return limit.NewLogger(logger) },
+	"redact":   func(logger logging.Logger) logging.Logger { return redact.NewLogger(logger) },
+	"sampling": func(logger logging.Logger) logging.Logger { return sampling.NewLogger(logger) },
+}
+
+func helper(logger logging.Logger) {
+	logging.AddCallerSkip(logger, 1).Infow("from helper")
+}
+
+// TestWrappers checks that the Loggers embedding logging.Wrapper derive
+// their children, share their level and report the caller.
+func TestWrappers(t *testing.T) {
+	for name, wrap := range wrappers {
+		t.Run(name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			inner := stream.NewLogger(buffer, stream.WithEncoder(&stream.LogfmtEncoder{}), stream.WithCaller(logging.CallerShort))
+			inner.SetLevel(logging.LevelTrace)
+			logger := wrap(inner)
+			logger.SetLevel(logging.LevelInfo)
+			child := logger.Named("child").With("key", "value")
+
+			child.Debug("hidden")
+			_, _, line, _ := runtime.Caller(0)
+			child.Infow("message", "other", 1)
+			helper(child)
+			if closer, ok := logger.(io.Closer); ok {
+				_ = closer.Close()
+			}
+
+			lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("got %d lines, want 2:\n%s", len(lines), buffer.String())
+			}
+			for i, want := range []string{
+				fmt.Sprintf("logger=child msg=message key=value other=1 caller=logging/wrapper_test.go:%d", line+1),
+				fmt.Sprintf("logger=child msg=\"from helper\" key=value caller=logging/wrapper_test.go:%d", line+2),
+			} {
+				if !strings.HasSuffix(lines[i], want) {
+					t.Errorf("line %d = %q, want suffix %q", i, lines[i], want)
+				}
+			}
+			if level := *child.GetLevel(); level != logging.LevelInfo {
+				t.Errorf("child level = %v, want %v", level, logging.LevelInfo)
+			}
+			if !child.Enabled(logging.LevelInfo) || child.Enabled(logging.LevelDebug) {
+				t.Errorf("child Enabled does not follow the parent's level")
+			}
+		})
+	}
+}
+
+func TestWrappersWithoutExit(t *testing.T) {
+	count := 0
+	defer logging.SetExitFunc(logging.SetExitFunc(func(int) { count++ }))
+	buffer := &bytes.Buffer{}
+	logger := async.NewLogger(stream.NewLogger(buffer, stream.WithEncoder(&stream.LogfmtEncoder{})))
+	defer logger.Close()
+
+	child, ok := logging.WithoutExit(logger)
+	if !ok {
+		t.Fatalf("%T is not an ExitLogger", logger)
+	}
+	child.Named("child").Fatal("message")
+	if count != 0 {
+		t.Errorf("the WithoutExit child exited")
+	}
+	if !strings.Contains(buffer.String(), "level=fatal logger=child msg=message") {
+		t.Errorf("the fatal entry was not written:\n%s", buffer.String())
+	}
+	logger.Fatal("message")
+	if count != 1 {
+		t.Errorf("exited %d times, want 1", count)
+	}
+}
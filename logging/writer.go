@@ -0,0 +1,173 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"sync"
+)
+
+// Writer is an io.Writer that splits its input into lines and logs each
+// line as a message, so that libraries writing diagnostics to an io.Writer
+// (e.g. http.Server.ErrorLog, through log.New) write to a Logger; partial
+// lines are kept until they are completed or the Writer is flushed.
+type Writer struct {
+	logger Logger
+	level  Level
+	detect bool
+	skip   int
+	lock   sync.Mutex
+	buffer []byte
+}
+
+// WriterOption is a functional option for configuring a Writer.
+type WriterOption func(*Writer)
+
+// WithLevelDetection makes the Writer log the lines starting with a level
+// name or tag, in brackets or followed by a colon (e.g. "[WARN]", "ERROR:"
+// or "[dbg]"), at that level and without the prefix; the other lines are
+// logged at the Writer's level. Lines are never logged above LevelError,
+// so that a write cannot panic or exit the application.
+func WithLevelDetection() WriterOption {
+	return func(w *Writer) {
+		w.detect = true
+	}
+}
+
+// WithCallerSkip makes the Writer report as caller the function skip
+// frames above the caller of Write, e.g. 2 for the callers of a log.Logger
+// writing to it; by default, the caller of Write is reported.
+func WithCallerSkip(skip int) WriterOption {
+	return func(w *Writer) {
+		w.skip = skip
+	}
+}
+
+// NewWriter returns a Writer logging to the given Logger at the given
+// level; if the Logger is nil, each line is logged to the global Logger,
+// as returned by GetLogger at the time of the write.
+func NewWriter(logger Logger, level Level, options ...WriterOption) *Writer {
+	w := &Writer{
+		logger: logger,
+		level:  level,
+	}
+	for _, option := range options {
+		option(w)
+	}
+	return w
+}
+
+// Write logs all the complete lines in the data and keeps the rest; it
+// never fails.
+func (w *Writer) Write(data []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.buffer = append(w.buffer, data...)
+	for {
+		i := bytes.IndexByte(w.buffer, '\n')
+		if i < 0 {
+			break
+		}
+		w.write(string(w.buffer[:i]))
+		w.buffer = w.buffer[i+1:]
+	}
+	if len(w.buffer) == 0 {
+		w.buffer = nil
+	}
+	return len(data), nil
+}
+
+// Flush logs the pending partial line, if any.
+func (w *Writer) Flush() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if len(w.buffer) > 0 {
+		w.write(string(w.buffer))
+		w.buffer = nil
+	}
+	return nil
+}
+
+// Close flushes the Writer; it does not close the Logger.
+func (w *Writer) Close() error {
+	return w.Flush()
+}
+
+// write logs a line; it must be called directly by Write and Flush, so
+// that the caller of Write is two frames above the logging method.
+func (w *Writer) write(line string) {
+	line = strings.TrimRight(line, "\r")
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	level := w.level
+	if w.detect {
+		if detected, message, ok := detectLevel(line); ok {
+			level, line = detected, message
+		}
+	}
+	if level > LevelError {
+		level = LevelError
+	}
+	logger := w.logger
+	if logger == nil {
+		logger = GetLogger()
+	}
+	logger = AddCallerSkip(logger, 2+w.skip)
+	switch level {
+	case LevelTrace:
+		logger.Trace(line)
+	case LevelDebug:
+		logger.Debug(line)
+	case LevelInfo:
+		logger.Info(line)
+	case LevelWarn:
+		logger.Warn(line)
+	default:
+		logger.Error(line)
+	}
+}
+
+// detectLevel returns the level named at the start of the line, as in
+// "[WARN] message" or "warning: message", and the rest of the line.
+func detectLevel(line string) (Level, string, bool) {
+	text := strings.TrimLeft(line, " \t")
+	var name, rest string
+	if strings.HasPrefix(text, "[") {
+		i := strings.IndexByte(text, ']')
+		if i < 0 {
+			return LevelOff, line, false
+		}
+		name, rest = text[1:i], text[i+1:]
+	} else {
+		i := strings.IndexByte(text, ':')
+		if i < 0 {
+			return LevelOff, line, false
+		}
+		name, rest = text[:i], text[i+1:]
+	}
+	level, ok := levelAliases[strings.ToLower(strings.TrimSpace(name))]
+	if !ok || level == LevelOff {
+		return LevelOff, line, false
+	}
+	return level, strings.TrimLeft(rest, " \t"), true
+}
+
+// RedirectStdLog redirects the output of the standard logger of the log
+// package to the global Logger, as returned by GetLogger at the time of
+// each write, at the given level; the standard logger's flags are cleared,
+// since the Logger adds its own timestamp and caller. It returns a function
+// restoring the previous output and flags. The global Logger must not
+// write to the standard logger itself, e.g. through golang.NewLoggerFrom(nil).
+func RedirectStdLog(level Level, options ...WriterOption) (restore func()) {
+	output, flags := log.Writer(), log.Flags()
+	// skip the log package's output method and the printing function
+	w := NewWriter(nil, level, append([]WriterOption{WithCallerSkip(2)}, options...)...)
+	log.SetFlags(0)
+	log.SetOutput(w)
+	return func() {
+		log.SetOutput(output)
+		log.SetFlags(flags)
+		_ = w.Flush()
+	}
+}
@@ -0,0 +1,148 @@
+package logging_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/dihedron/go-log-facade/logging"
+	"github.com/dihedron/go-log-facade/logging/stream"
+)
+
+// newStream returns a logfmt stream Logger at LevelTrace reporting the
+// short location of the calls, and the buffer it writes to.
+func newStream() (*stream.Logger, *bytes.Buffer) {
+	buffer := &bytes.Buffer{}
+	logger := stream.NewLogger(buffer, stream.WithEncoder(&stream.LogfmtEncoder{}), stream.WithCaller(logging.CallerShort))
+	logger.SetLevel(logging.LevelTrace)
+	return logger, buffer
+}
+
+// entries returns the lines written to the buffer without the timestamp
+// and the caller, and empties it.
+func entries(buffer *bytes.Buffer) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		_, line, _ = strings.Cut(line, " ")
+		line, _, _ = strings.Cut(line, " caller=")
+		lines = append(lines, line)
+	}
+	buffer.Reset()
+	return lines
+}
+
+func TestWriterLines(t *testing.T) {
+	logger, buffer := newStream()
+	w := logging.NewWriter(logger, logging.LevelInfo)
+
+	for _, data := range []string{"first ", "line\nsecond", " line\r\n", "\n  \n", "third\r\nfour", "th"} {
+		if n, err := io.WriteString(w, data); n != len(data) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", data, n, err)
+		}
+	}
+	want := []string{
+		`level=info msg="first line"`,
+		`level=info msg="second line"`,
+		`level=info msg=third`,
+	}
+	if got := entries(buffer); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// the trailing partial line is logged on Flush and Close
+	w.Flush()
+	if got := entries(buffer); len(got) != 1 || got[0] != `level=info msg=fourth` {
+		t.Errorf("got %q after Flush", got)
+	}
+	w.Flush()
+	io.WriteString(w, "fifth")
+	w.Close()
+	if got := entries(buffer); len(got) != 1 || got[0] != `level=info msg=fifth` {
+		t.Errorf("got %q after Close", got)
+	}
+}
+
+func TestWriterLevelDetection(t *testing.T) {
+	tests := []struct {
+		line, want string
+	}{
+		{"[WARN] message", `level=warn msg=message`},
+		{"  [dbg]message", `level=debug msg=message`},
+		{"error: message", `level=error msg=message`},
+		{"Warning:  message", `level=warn msg=message`},
+		{"[trace] message", `level=trace msg=message`},
+		{"[off] message", `level=info msg="[off] message"`},
+		{"[unknown] message", `level=info msg="[unknown] message"`},
+		{"[unterminated message", `level=info msg="[unterminated message"`},
+		{"no level", `level=info msg="no level"`},
+		{"[FATAL] message", `level=error msg=message`},
+		{"panic: message", `level=error msg=message`},
+	}
+	logger, buffer := newStream()
+	w := logging.NewWriter(logger, logging.LevelInfo, logging.WithLevelDetection())
+	for _, test := range tests {
+		fmt.Fprintln(w, test.line)
+		if got := entries(buffer); len(got) != 1 || got[0] != test.want {
+			t.Errorf("%q: got %q, want %q", test.line, got, test.want)
+		}
+	}
+
+	// without detection, the prefixes are kept and the level is capped
+	w = logging.NewWriter(logger, logging.LevelFatal)
+	fmt.Fprintln(w, "[WARN] message")
+	if got := entries(buffer); len(got) != 1 || got[0] != `level=error msg="[WARN] message"` {
+		t.Errorf("got %q without detection", got)
+	}
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	previous := logging.GetLogger()
+	defer logging.SetLogger(previous)
+	logger, buffer := newStream()
+	logging.SetLogger(logger)
+
+	output, flags := log.Writer(), log.Flags()
+	restore := logging.RedirectStdLog(logging.LevelWarn, logging.WithLevelDetection())
+	_, _, first, _ := runtime.Caller(0)
+	log.Print("message")
+	log.Printf("[%s] detected", "debug")
+	log.Println("line")
+	restore()
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	want := []string{
+		`level=warn msg=message`,
+		`level=debug msg=detected`,
+		`level=warn msg=line`,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), buffer.String())
+	}
+	for i, line := range lines {
+		if !strings.Contains(line, " "+want[i]+" ") {
+			t.Errorf("line %d = %s, want %s", i, line, want[i])
+		}
+		// the caller is the function calling the log package
+		if caller := fmt.Sprintf(" caller=logging/writer_test.go:%d", first+1+i); !strings.HasSuffix(line, caller) {
+			t.Errorf("line %d = %s, want the caller%s", i, line, caller)
+		}
+	}
+
+	if log.Writer() != output || log.Flags() != flags {
+		t.Errorf("the standard logger was not restored")
+	}
+	buffer.Reset()
+	log.SetOutput(io.Discard)
+	log.Print("after restore")
+	log.SetOutput(output)
+	if buffer.Len() != 0 {
+		t.Errorf("got %s after restore", buffer.String())
+	}
+}